@@ -12,6 +12,7 @@ import (
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 
+	"zckyachmd/lifeline/internal/alerts"
 	api "zckyachmd/lifeline/internal/api"
 	"zckyachmd/lifeline/internal/auth"
 	"zckyachmd/lifeline/internal/config"
@@ -21,10 +22,15 @@ import (
 	"zckyachmd/lifeline/internal/security/confirm"
 	rl "zckyachmd/lifeline/internal/security/ratelimit"
 	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/docker"
+	"zckyachmd/lifeline/pkg/executor"
 	"zckyachmd/lifeline/pkg/jailer"
 	"zckyachmd/lifeline/pkg/logger"
 )
 
+// version is set at build time via -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -60,10 +66,21 @@ func main() {
 	}
 	_, _ = jail.EnsureDir("snapshots")
 
+	runner, err := executor.New(cfg.ExecBinaries(), cfg.Exec.MaxOutputKB*1024)
+	if err != nil {
+		log.Fatalf("executor init: %v", err)
+	}
+	for _, name := range runner.Missing() {
+		logg.Warn().Str("binary", name).Msg("configured binary not found, commands using it are disabled")
+	}
+
 	dsmClient := api.NewClient(cfg.DSM.BaseURL, cfg.DSM.APIToken)
-	monitor := services.NewMonitoring(dsmClient)
-	sys := &services.SystemService{}
-	snap := services.NewSnapshot(monitor, sys)
+	dockerRT := services.NewDockerRuntime(docker.New(cfg.Docker.Socket, cfg.DockerTimeout()), runner, cfg.Docker.CLIFallback)
+	monitor := services.NewMonitoring(dsmClient, dockerRT, runner, cfg)
+	sys := services.NewSystem(dsmClient, dockerRT, runner, cfg)
+	probes := services.NewProbes(cfg.Probes, runner)
+	snap := services.NewSnapshot(monitor, sys, probes)
+	runbooks := services.NewRunbooks(monitor, sys, cfg.Runbooks)
 	files := services.NewFileService(jail, cfg.Sandbox.MaxFileMB)
 
 	authz := auth.New(cfg.Telegram.AdminChatIDs)
@@ -82,7 +99,30 @@ func main() {
 	auditPath := filepath.Join(cfg.Sandbox.Root, "audit.log")
 	auditLog := audit.New(auditPath)
 
-	bot := handlers.New(botAPI, authz, limiter, confirmMgr, modes, auditLog, monitor, files, sys, snap, logg, cfg.Sandbox.Root, cfg.ConfirmTTL(), cfg.Telegram.PollTimeout)
+	runState := services.NewRunMarker(filepath.Join(cfg.Sandbox.Root, "run-state.json"))
+	prevRun, hadPrev, err := runState.Start(version)
+	if err != nil {
+		logg.Warn().Err(err).Msg("write run state failed")
+	}
+
+	bot := handlers.New(botAPI, handlers.Options{
+		Auth:       authz,
+		Limiter:    limiter,
+		Confirm:    confirmMgr,
+		Modes:      modes,
+		Audit:      auditLog,
+		Monitor:    monitor,
+		Files:      files,
+		System:     sys,
+		Snapshot:   snap,
+		Runbooks:   runbooks,
+		Probes:     probes,
+		RunState:   runState,
+		Logger:     logg,
+		Sandbox:    cfg.Sandbox.Root,
+		ConfirmTTL: cfg.ConfirmTTL(),
+		PollWait:   cfg.Telegram.PollTimeout,
+	})
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -101,6 +141,30 @@ func main() {
 		}
 	}()
 
+	// background alert conditions, notify admins on state change
+	if len(cfg.Alerts.Conditions) > 0 {
+		watcher := alerts.New(cfg.AlertInterval(), bot.Notify, logg)
+		available := map[string]alerts.CheckFunc{
+			"ups_on_battery":       monitor.UPSOnBatteryCheck,
+			"cloudflared_down":     monitor.CloudflaredDownCheck,
+			"container_crash_loop": monitor.CrashLoopCheck,
+		}
+		for _, name := range probes.Names() {
+			available["probe:"+name] = probes.Check(name)
+		}
+		for _, name := range cfg.Alerts.Conditions {
+			check, ok := available[name]
+			if !ok {
+				logg.Warn().Str("alert", name).Msg("unknown alert condition")
+				continue
+			}
+			watcher.Add(alerts.Condition{Name: name, Check: check})
+		}
+		go watcher.Run(ctx)
+	}
+
+	go bot.AnnounceStartup(ctx, version, prevRun, hadPrev)
+
 	// health endpoint on localhost for container orchestration
 	go func() {
 		defer func() {
@@ -114,6 +178,9 @@ func main() {
 	if err := bot.Start(ctx); err != nil {
 		logg.Error().Err(err).Msg("bot stopped")
 	}
+	if err := runState.MarkStopped(); err != nil {
+		logg.Warn().Err(err).Msg("write run state failed")
+	}
 }
 
 func startHealthServer() {
@@ -0,0 +1,93 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// CheckFunc evaluates a condition; firing=true means the alert is active.
+type CheckFunc func(ctx context.Context) (firing bool, detail string, err error)
+
+// Condition is a named alert check.
+type Condition struct {
+	Name  string
+	Check CheckFunc
+}
+
+// Watcher polls conditions and notifies on state transitions only.
+type Watcher struct {
+	interval time.Duration
+	notify   func(text string)
+	logger   zerolog.Logger
+	mu       sync.Mutex
+	conds    []Condition
+	firing   map[string]bool
+}
+
+// New creates a watcher that calls notify for every fired or resolved alert.
+func New(interval time.Duration, notify func(text string), logger zerolog.Logger) *Watcher {
+	return &Watcher{
+		interval: interval,
+		notify:   notify,
+		logger:   logger,
+		firing:   make(map[string]bool),
+	}
+}
+
+// Add registers a condition.
+func (w *Watcher) Add(c Condition) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.conds = append(w.conds, c)
+}
+
+// Len returns number of registered conditions.
+func (w *Watcher) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.conds)
+}
+
+// Run evaluates conditions every interval until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.Evaluate(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate runs one pass over all conditions.
+func (w *Watcher) Evaluate(ctx context.Context) {
+	w.mu.Lock()
+	conds := append([]Condition(nil), w.conds...)
+	w.mu.Unlock()
+
+	for _, c := range conds {
+		firing, detail, err := c.Check(ctx)
+		if err != nil {
+			w.logger.Warn().Err(err).Str("alert", c.Name).Msg("alert check failed")
+			continue
+		}
+		w.mu.Lock()
+		was := w.firing[c.Name]
+		w.firing[c.Name] = firing
+		w.mu.Unlock()
+
+		switch {
+		case firing && !was:
+			w.notify(fmt.Sprintf("ALERT %s: %s", c.Name, detail))
+		case !firing && was:
+			w.notify(fmt.Sprintf("RESOLVED %s: %s", c.Name, detail))
+		}
+	}
+}
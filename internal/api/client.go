@@ -121,6 +121,81 @@ func (c *Client) RestartService(ctx context.Context, service string) error {
 	return err
 }
 
+// Packages lists installed packages with their running status.
+func (c *Client) Packages(ctx context.Context) (map[string]any, error) {
+	return c.get(ctx, "/webapi/entry.cgi", url.Values{
+		"api":        {"SYNO.Core.Package"},
+		"version":    {"2"},
+		"method":     {"list"},
+		"additional": {`["status"]`},
+	})
+}
+
+// PackageControl starts or stops a package by id (method is "start" or "stop").
+func (c *Client) PackageControl(ctx context.Context, id, method string) (map[string]any, error) {
+	return c.get(ctx, "/webapi/entry.cgi", url.Values{
+		"api":     {"SYNO.Core.Package.Control"},
+		"version": {"1"},
+		"method":  {method},
+		"id":      {id},
+	})
+}
+
+// StorageInfo fetches storage pools, volumes and disks.
+func (c *Client) StorageInfo(ctx context.Context) (map[string]any, error) {
+	return c.get(ctx, "/webapi/entry.cgi", url.Values{
+		"api":     {"SYNO.Storage.CGI.Storage"},
+		"version": {"1"},
+		"method":  {"load_info"},
+	})
+}
+
+// BackupTasks lists Hyper Backup tasks with last result and progress.
+func (c *Client) BackupTasks(ctx context.Context) (map[string]any, error) {
+	return c.get(ctx, "/webapi/entry.cgi", url.Values{
+		"api":        {"SYNO.Backup.Task"},
+		"version":    {"1"},
+		"method":     {"list"},
+		"additional": {`["last_bkp_time","last_bkp_result","last_bkp_progress","next_bkp_time"]`},
+	})
+}
+
+// ScheduledTasks lists DSM Task Scheduler entries.
+func (c *Client) ScheduledTasks(ctx context.Context) (map[string]any, error) {
+	return c.get(ctx, "/webapi/entry.cgi", url.Values{
+		"api":     {"SYNO.Core.TaskScheduler"},
+		"version": {"2"},
+		"method":  {"list"},
+	})
+}
+
+// UPSInfo fetches UPS state from DSM's UPS support.
+func (c *Client) UPSInfo(ctx context.Context) (map[string]any, error) {
+	return c.get(ctx, "/webapi/entry.cgi", url.Values{
+		"api":     {"SYNO.Core.ExternalDevice.UPS"},
+		"version": {"1"},
+		"method":  {"get"},
+	})
+}
+
+// DDNSRecords lists configured DDNS records and their last registered IP.
+func (c *Client) DDNSRecords(ctx context.Context) (map[string]any, error) {
+	return c.get(ctx, "/webapi/entry.cgi", url.Values{
+		"api":     {"SYNO.Core.DDNS.Record"},
+		"version": {"1"},
+		"method":  {"list"},
+	})
+}
+
+// DDNSUpdate asks DSM to push the current IP to all DDNS providers.
+func (c *Client) DDNSUpdate(ctx context.Context) (map[string]any, error) {
+	return c.get(ctx, "/webapi/entry.cgi", url.Values{
+		"api":     {"SYNO.Core.DDNS.Record"},
+		"version": {"1"},
+		"method":  {"update_ip_address"},
+	})
+}
+
 // RotateToken refreshes API token placeholder.
 func (c *Client) RotateToken(newToken string) {
 	c.token = newToken
@@ -25,6 +25,17 @@ func (a *Authorizer) IsAllowed(userID int64) bool {
 	return ok
 }
 
+// IDs returns allowlisted ids (used for admin notifications).
+func (a *Authorizer) IDs() []int64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	ids := make([]int64, 0, len(a.allowed))
+	for id := range a.allowed {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 // Add adds a new id (not used runtime but testable).
 func (a *Authorizer) Add(id int64) {
 	a.mu.Lock()
@@ -3,7 +3,11 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -11,13 +15,30 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+var composeProjectRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
 // AppConfig holds all configuration loaded from env or YAML.
 type AppConfig struct {
-	Telegram TelegramConfig `yaml:"telegram"`
-	DSM      DSMConfig      `yaml:"dsm"`
-	Security SecurityConfig `yaml:"security"`
-	Logging  LoggingConfig  `yaml:"logging"`
-	Sandbox  SandboxConfig  `yaml:"sandbox"`
+	Telegram    TelegramConfig    `yaml:"telegram"`
+	DSM         DSMConfig         `yaml:"dsm"`
+	Security    SecurityConfig    `yaml:"security"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Sandbox     SandboxConfig     `yaml:"sandbox"`
+	Packages    PackagesConfig    `yaml:"packages"`
+	Storage     StorageConfig     `yaml:"storage"`
+	UPS         UPSConfig         `yaml:"ups"`
+	Alerts      AlertsConfig      `yaml:"alerts"`
+	Docker      DockerConfig      `yaml:"docker"`
+	Containers  ContainersConfig  `yaml:"containers"`
+	Compose     []ComposeStack    `yaml:"compose"`
+	Cleanup     CleanupConfig     `yaml:"cleanup"`
+	DU          DUConfig          `yaml:"du"`
+	Exec        ExecConfig        `yaml:"exec"`
+	Verify      VerifyConfig      `yaml:"verify"`
+	Cloudflared CloudflaredConfig `yaml:"cloudflared"`
+	Runbooks    []Runbook         `yaml:"runbooks"`
+	Wake        WakeConfig        `yaml:"wake"`
+	Probes      []Probe           `yaml:"probes"`
 }
 
 // TelegramConfig describes Telegram bot settings.
@@ -54,6 +75,171 @@ type SandboxConfig struct {
 	MaxFileMB int    `yaml:"max_file_mb"`
 }
 
+// PackagesConfig lists Synology packages that may be restarted.
+type PackagesConfig struct {
+	Allowed []string `yaml:"allowed"`
+}
+
+// StorageConfig controls local RAID and SMART inspection.
+type StorageConfig struct {
+	MDStatPath   string   `yaml:"mdstat_path"`
+	SmartctlPath string   `yaml:"smartctl_path"` // empty disables SMART queries
+	SmartDisks   []string `yaml:"smart_disks"`
+}
+
+// UPSConfig selects the UPS data source ("dsm", "nut" or empty to disable).
+type UPSConfig struct {
+	Source      string `yaml:"source"`
+	NUTAddr     string `yaml:"nut_addr"`
+	NUTName     string `yaml:"nut_ups"`
+	NUTUser     string `yaml:"nut_user"`
+	NUTPassword string `yaml:"nut_password"`
+}
+
+// AlertsConfig enables background alert conditions sent to admins.
+type AlertsConfig struct {
+	IntervalSeconds int      `yaml:"interval_seconds"`
+	Conditions      []string `yaml:"conditions"`
+}
+
+// DockerConfig points at the Engine API socket.
+type DockerConfig struct {
+	Socket         string `yaml:"socket"`
+	TimeoutSeconds int    `yaml:"timeout_seconds"`
+	CLIFallback    bool   `yaml:"cli_fallback"` // use docker CLI when the socket is unreachable
+}
+
+// ContainersConfig lists extra containers that /restart may touch.
+type ContainersConfig struct {
+	RestartAllowed []string `yaml:"restart_allowed"`
+}
+
+// ComposeStack declares a compose project that /restart may recycle.
+type ComposeStack struct {
+	Name    string `yaml:"name"`
+	Project string `yaml:"project"`
+	File    string `yaml:"file"`
+	Action  string `yaml:"action"` // "restart" (default) or "recreate" (down + up -d)
+}
+
+// CleanupConfig bounds /cleanup logs to container logs and allowlisted log dirs.
+type CleanupConfig struct {
+	LogDirs       []string `yaml:"log_dirs"`
+	DockerRoot    string   `yaml:"docker_root"`
+	MinLogMB      int      `yaml:"min_log_mb"`
+	MaxCandidates int      `yaml:"max_candidates"`
+}
+
+// DUConfig maps /du aliases to directories and bounds each walk.
+type DUConfig struct {
+	Paths          map[string]string `yaml:"paths"`
+	MaxEntries     int               `yaml:"max_entries"`
+	TimeoutSeconds int               `yaml:"timeout_seconds"`
+	Top            int               `yaml:"top"`
+}
+
+// VerifyConfig bounds the health polling after a service restart.
+type VerifyConfig struct {
+	TimeoutSeconds  int `yaml:"timeout_seconds"`
+	IntervalSeconds int `yaml:"interval_seconds"`
+}
+
+// CloudflaredConfig points at cloudflared's metrics server (--metrics).
+type CloudflaredConfig struct {
+	MetricsAddr string `yaml:"metrics_addr"` // host:port, empty disables the /ready probe
+}
+
+// WakeConfig maps /wake aliases to Wake-on-LAN targets.
+type WakeConfig struct {
+	Hosts       map[string]WakeHost `yaml:"hosts"`
+	PollSeconds int                 `yaml:"poll_seconds"` // how long to wait for a host with a check to come up
+}
+
+// WakeHost is one Wake-on-LAN target.
+type WakeHost struct {
+	MAC       string `yaml:"mac"`
+	Broadcast string `yaml:"broadcast"` // IPv4 broadcast; defaults to the interface's, else 255.255.255.255
+	Interface string `yaml:"interface"` // optional NIC to send from, e.g. eth0
+	Port      int    `yaml:"port"`      // UDP port, default 9
+	Check     string `yaml:"check"`     // optional host (ping) or host:port (TCP) polled after waking
+}
+
+const (
+	maxWakePoll     = 15 * time.Minute
+	defaultWakePort = 9
+)
+
+// Probe is a named reachability check run by /probe, alerts and snapshots.
+type Probe struct {
+	Name           string `yaml:"name"`
+	Type           string `yaml:"type"`            // icmp | tcp | http | tls
+	Target         string `yaml:"target"`          // host (icmp), host:port (tcp, tls) or URL (http)
+	TimeoutSeconds int    `yaml:"timeout_seconds"` // default 5
+	ExpectStatus   int    `yaml:"expect_status"`   // http: exact status, default any 2xx/3xx
+	BodyMatch      string `yaml:"body_match"`      // http: regexp the body must match
+	Insecure       bool   `yaml:"insecure"`        // http: accept self-signed certificates
+	MinDaysValid   int    `yaml:"min_days_valid"`  // tls: fail when the certificate expires sooner, default 14
+}
+
+const (
+	maxProbeTimeout     = 60
+	defaultProbeTimeout = 5
+	defaultProbeMinDays = 14
+)
+
+// Runbook is a named recovery procedure built from read-only checks,
+// allowlisted actions, waits and verifications.
+type Runbook struct {
+	Name        string        `yaml:"name"`
+	Description string        `yaml:"description"`
+	Steps       []RunbookStep `yaml:"steps"`
+}
+
+// RunbookStep does exactly one of check, action, wait or verify. The
+// optional if_healthy/if_unhealthy condition skips the step unless it holds.
+type RunbookStep struct {
+	Check       string `yaml:"check"`  // health|status|resources|storage|backups|ups|diag_net|diag_time|ip
+	Action      string `yaml:"action"` // "restart <svc>", "pkg restart <id>" or "ddns update"
+	Wait        string `yaml:"wait"`   // duration, e.g. 30s
+	Verify      string `yaml:"verify"` // service to health-check; failure stops the run
+	IfHealthy   string `yaml:"if_healthy"`
+	IfUnhealthy string `yaml:"if_unhealthy"`
+}
+
+// BuiltinServices are the host services /restart and runbooks accept besides
+// allowlisted containers and compose stacks.
+var BuiltinServices = []string{"tailscale", "tailscaled", "cloudflared", "docker"}
+
+// IsBuiltinService reports whether name is one of BuiltinServices (case-insensitive).
+func IsBuiltinService(name string) bool {
+	for _, v := range BuiltinServices {
+		if strings.EqualFold(v, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunbookChecks are the read-only checks a runbook step may run.
+var RunbookChecks = map[string]bool{
+	"health": true, "status": true, "resources": true, "storage": true, "backups": true,
+	"ups": true, "diag_net": true, "diag_time": true, "ip": true,
+}
+
+const (
+	maxRunbookSteps = 30
+	maxRunbookWait  = 10 * time.Minute
+)
+
+// ExecConfig pins external binaries to absolute paths and caps their output.
+type ExecConfig struct {
+	Binaries    map[string]string `yaml:"binaries"`
+	MaxOutputKB int               `yaml:"max_output_kb"`
+}
+
+// execBinaries are the binary names services may run.
+var execBinaries = map[string]bool{"docker": true, "systemctl": true, "journalctl": true, "ping": true, "timedatectl": true, "tailscale": true, "dmesg": true}
+
 // Load reads YAML config (if present) and overrides with env vars.
 func Load(path string) (*AppConfig, error) {
 	cfg := defaultConfig()
@@ -71,6 +257,7 @@ func Load(path string) (*AppConfig, error) {
 	}
 
 	overrideFromEnv(cfg)
+	cfg.normalize()
 
 	if err := cfg.validate(); err != nil {
 		return nil, err
@@ -93,6 +280,43 @@ func defaultConfig() *AppConfig {
 			DefaultMode:       "readonly",
 		},
 		Logging: LoggingConfig{Level: "info"},
+		Storage: StorageConfig{
+			MDStatPath: "/proc/mdstat",
+		},
+		UPS: UPSConfig{
+			NUTAddr: "127.0.0.1:3493",
+			NUTName: "ups",
+		},
+		Alerts: AlertsConfig{IntervalSeconds: 60},
+		Cleanup: CleanupConfig{
+			LogDirs:       []string{"/var/log"},
+			DockerRoot:    "/volume1/@docker",
+			MinLogMB:      50,
+			MaxCandidates: 10,
+		},
+		DU: DUConfig{
+			MaxEntries:     200000,
+			TimeoutSeconds: 20,
+			Top:            10,
+		},
+		Exec: ExecConfig{
+			Binaries: map[string]string{
+				"docker":      "/usr/local/bin/docker",
+				"systemctl":   "/usr/bin/systemctl",
+				"journalctl":  "/usr/bin/journalctl",
+				"ping":        "/bin/ping",
+				"timedatectl": "/usr/bin/timedatectl",
+				"tailscale":   "/var/packages/Tailscale/target/bin/tailscale",
+				"dmesg":       "/bin/dmesg",
+			},
+			MaxOutputKB: 512,
+		},
+		Verify: VerifyConfig{TimeoutSeconds: 90, IntervalSeconds: 3},
+		Wake:   WakeConfig{PollSeconds: 180},
+		Docker: DockerConfig{
+			Socket:         "/var/run/docker.sock",
+			TimeoutSeconds: 10,
+		},
 		Sandbox: SandboxConfig{
 			Root:      "/emergency-files",
 			MaxFileMB: 50,
@@ -149,6 +373,32 @@ func overrideFromEnv(cfg *AppConfig) {
 	}
 }
 
+// normalize fills per-entry defaults that defaultConfig cannot express
+// (list and map items), so validate stays free of side effects.
+func (c *AppConfig) normalize() {
+	c.Security.DefaultMode = strings.ToLower(c.Security.DefaultMode)
+	for i := range c.Compose {
+		if c.Compose[i].Action == "" {
+			c.Compose[i].Action = "restart"
+		}
+	}
+	for alias, h := range c.Wake.Hosts {
+		if h.Port == 0 {
+			h.Port = defaultWakePort
+			c.Wake.Hosts[alias] = h
+		}
+	}
+	for i := range c.Probes {
+		p := &c.Probes[i]
+		if p.TimeoutSeconds == 0 {
+			p.TimeoutSeconds = defaultProbeTimeout
+		}
+		if p.Type == "tls" && p.MinDaysValid == 0 {
+			p.MinDaysValid = defaultProbeMinDays
+		}
+	}
+}
+
 func (c *AppConfig) validate() error {
 	if c.Telegram.Token == "" {
 		return errors.New("telegram token required")
@@ -165,21 +415,247 @@ func (c *AppConfig) validate() error {
 	if c.Security.ConfirmTTLSeconds <= 0 {
 		return errors.New("confirm ttl must be >0")
 	}
-	mode := strings.ToLower(c.Security.DefaultMode)
-	switch mode {
+	if c.Docker.Socket == "" || c.Docker.TimeoutSeconds <= 0 {
+		return errors.New("docker socket and timeout required")
+	}
+	seen := map[string]bool{"cloudflared": true, "tailscale": true, "tailscaled": true, "docker": true}
+	for i, st := range c.Compose {
+		if st.Name == "" || st.Project == "" || !filepath.IsAbs(st.File) {
+			return fmt.Errorf("compose stack %d: name, project and absolute file required", i)
+		}
+		if !composeProjectRe.MatchString(st.Project) {
+			return fmt.Errorf("compose stack %s: invalid project name", st.Name)
+		}
+		if seen[st.Name] {
+			return fmt.Errorf("compose stack %s: duplicate or reserved name", st.Name)
+		}
+		seen[st.Name] = true
+		switch st.Action {
+		case "restart", "recreate":
+		default:
+			return fmt.Errorf("compose stack %s: invalid action %s", st.Name, st.Action)
+		}
+	}
+	for _, d := range append([]string{c.Cleanup.DockerRoot}, c.Cleanup.LogDirs...) {
+		if d != "" && (!filepath.IsAbs(d) || filepath.Clean(d) == "/") {
+			return fmt.Errorf("cleanup path must be absolute and not /: %s", d)
+		}
+	}
+	if c.Cleanup.MinLogMB <= 0 || c.Cleanup.MaxCandidates <= 0 {
+		return errors.New("cleanup min_log_mb and max_candidates must be >0")
+	}
+	for alias, p := range c.DU.Paths {
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("du path %s must be absolute", alias)
+		}
+	}
+	if c.DU.MaxEntries <= 0 || c.DU.TimeoutSeconds <= 0 || c.DU.Top <= 0 {
+		return errors.New("du max_entries, timeout_seconds and top must be >0")
+	}
+	for name, p := range c.Exec.Binaries {
+		if !execBinaries[name] {
+			return fmt.Errorf("exec binary %s: unknown name", name)
+		}
+		if p != "" && !filepath.IsAbs(p) {
+			return fmt.Errorf("exec binary %s: path must be absolute", name)
+		}
+	}
+	if c.Storage.SmartctlPath != "" && !filepath.IsAbs(c.Storage.SmartctlPath) {
+		return errors.New("smartctl path must be absolute")
+	}
+	if c.Verify.TimeoutSeconds <= 0 || c.Verify.IntervalSeconds <= 0 {
+		return errors.New("verify timeout_seconds and interval_seconds must be >0")
+	}
+	if c.Cloudflared.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(c.Cloudflared.MetricsAddr); err != nil {
+			return fmt.Errorf("cloudflared metrics_addr: %w", err)
+		}
+	}
+	if c.Exec.MaxOutputKB <= 0 {
+		return errors.New("exec max_output_kb must be >0")
+	}
+	if err := c.validateRunbooks(); err != nil {
+		return err
+	}
+	if err := c.validateWake(); err != nil {
+		return err
+	}
+	if err := c.validateProbes(); err != nil {
+		return err
+	}
+	switch c.UPS.Source {
+	case "", "dsm", "nut":
+	default:
+		return fmt.Errorf("invalid ups source: %s", c.UPS.Source)
+	}
+	if len(c.Alerts.Conditions) > 0 && c.Alerts.IntervalSeconds <= 0 {
+		return errors.New("alert interval must be >0")
+	}
+	switch c.Security.DefaultMode {
 	case "readonly", "emergency", "lockdown":
-		c.Security.DefaultMode = mode
 	default:
 		return fmt.Errorf("invalid default mode: %s", c.Security.DefaultMode)
 	}
 	return nil
 }
 
+func (c *AppConfig) validateRunbooks() error {
+	containers := map[string]bool{}
+	for _, n := range c.Containers.RestartAllowed {
+		containers[n] = true
+	}
+	services := func(name string) bool { return IsBuiltinService(name) || containers[name] }
+	stacks := map[string]bool{}
+	for _, st := range c.Compose {
+		stacks[st.Name] = true
+	}
+	restartable := func(name string) bool { return services(name) || stacks[name] }
+	packages := map[string]bool{}
+	for _, p := range c.Packages.Allowed {
+		packages[p] = true
+	}
+
+	seen := map[string]bool{}
+	for _, rb := range c.Runbooks {
+		if !composeProjectRe.MatchString(rb.Name) || seen[rb.Name] {
+			return fmt.Errorf("runbook %q: invalid or duplicate name", rb.Name)
+		}
+		seen[rb.Name] = true
+		if len(rb.Steps) == 0 || len(rb.Steps) > maxRunbookSteps {
+			return fmt.Errorf("runbook %s: needs 1-%d steps", rb.Name, maxRunbookSteps)
+		}
+		for i, st := range rb.Steps {
+			where := fmt.Sprintf("runbook %s step %d", rb.Name, i+1)
+			set := 0
+			for _, v := range []string{st.Check, st.Action, st.Wait, st.Verify} {
+				if v != "" {
+					set++
+				}
+			}
+			if set != 1 {
+				return fmt.Errorf("%s: exactly one of check, action, wait, verify required", where)
+			}
+			if st.IfHealthy != "" && st.IfUnhealthy != "" {
+				return fmt.Errorf("%s: use only one of if_healthy, if_unhealthy", where)
+			}
+			if cond := st.IfHealthy + st.IfUnhealthy; cond != "" && !services(cond) {
+				return fmt.Errorf("%s: unknown service %s in condition", where, cond)
+			}
+			switch {
+			case st.Check != "" && !RunbookChecks[st.Check]:
+				return fmt.Errorf("%s: unknown check %s", where, st.Check)
+			case st.Verify != "" && !services(st.Verify):
+				return fmt.Errorf("%s: cannot verify %s", where, st.Verify)
+			case st.Wait != "":
+				d, err := time.ParseDuration(st.Wait)
+				if err != nil || d <= 0 || d > maxRunbookWait {
+					return fmt.Errorf("%s: wait must be a duration up to %s", where, maxRunbookWait)
+				}
+			case st.Action != "":
+				f := strings.Fields(st.Action)
+				switch {
+				case len(f) == 2 && f[0] == "restart" && restartable(f[1]):
+				case len(f) == 3 && f[0] == "pkg" && f[1] == "restart" && packages[f[2]]:
+				case len(f) == 2 && f[0] == "ddns" && f[1] == "update":
+				default:
+					return fmt.Errorf("%s: action %q not allowlisted", where, st.Action)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *AppConfig) validateWake() error {
+	if c.Wake.PollSeconds <= 0 || time.Duration(c.Wake.PollSeconds)*time.Second > maxWakePoll {
+		return fmt.Errorf("wake poll_seconds must be 1-%d", int(maxWakePoll.Seconds()))
+	}
+	for alias, h := range c.Wake.Hosts {
+		if !composeProjectRe.MatchString(alias) {
+			return fmt.Errorf("wake host %q: invalid alias", alias)
+		}
+		if mac, err := net.ParseMAC(h.MAC); err != nil || len(mac) != 6 {
+			return fmt.Errorf("wake host %s: invalid mac %q", alias, h.MAC)
+		}
+		if h.Broadcast != "" && net.ParseIP(h.Broadcast).To4() == nil {
+			return fmt.Errorf("wake host %s: broadcast must be an IPv4 address", alias)
+		}
+		if h.Port <= 0 || h.Port > 65535 {
+			return fmt.Errorf("wake host %s: invalid port %d", alias, h.Port)
+		}
+		if strings.Contains(h.Check, ":") {
+			if _, _, err := net.SplitHostPort(h.Check); err != nil {
+				return fmt.Errorf("wake host %s check: %w", alias, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *AppConfig) validateProbes() error {
+	seen := map[string]bool{}
+	for _, p := range c.Probes {
+		if !composeProjectRe.MatchString(p.Name) || seen[p.Name] {
+			return fmt.Errorf("probe %q: invalid or duplicate name", p.Name)
+		}
+		seen[p.Name] = true
+		if p.Target == "" {
+			return fmt.Errorf("probe %s: target required", p.Name)
+		}
+		switch p.Type {
+		case "icmp":
+		case "tcp", "tls":
+			if _, _, err := net.SplitHostPort(p.Target); err != nil {
+				return fmt.Errorf("probe %s: target must be host:port: %w", p.Name, err)
+			}
+		case "http":
+			u, err := url.Parse(p.Target)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("probe %s: target must be an http(s) URL", p.Name)
+			}
+			if _, err := regexp.Compile(p.BodyMatch); err != nil {
+				return fmt.Errorf("probe %s: body_match: %w", p.Name, err)
+			}
+		default:
+			return fmt.Errorf("probe %s: type must be icmp|tcp|http|tls", p.Name)
+		}
+		if p.TimeoutSeconds <= 0 || p.TimeoutSeconds > maxProbeTimeout {
+			return fmt.Errorf("probe %s: timeout_seconds must be 1-%d", p.Name, maxProbeTimeout)
+		}
+		if p.MinDaysValid < 0 {
+			return fmt.Errorf("probe %s: min_days_valid must be >=0", p.Name)
+		}
+	}
+	return nil
+}
+
 // ConfirmTTL returns TTL as duration.
 func (c *AppConfig) ConfirmTTL() time.Duration {
 	return time.Duration(c.Security.ConfirmTTLSeconds) * time.Second
 }
 
+// AlertInterval returns how often alert conditions are evaluated.
+func (c *AppConfig) AlertInterval() time.Duration {
+	return time.Duration(c.Alerts.IntervalSeconds) * time.Second
+}
+
+// DockerTimeout returns the per-request Docker API timeout.
+func (c *AppConfig) DockerTimeout() time.Duration {
+	return time.Duration(c.Docker.TimeoutSeconds) * time.Second
+}
+
+// ExecBinaries returns every binary the executor should validate, including smartctl.
+func (c *AppConfig) ExecBinaries() map[string]string {
+	out := make(map[string]string, len(c.Exec.Binaries)+1)
+	for name, p := range c.Exec.Binaries {
+		out[name] = p
+	}
+	if c.Storage.SmartctlPath != "" {
+		out["smartctl"] = c.Storage.SmartctlPath
+	}
+	return out
+}
+
 // TokenRefreshInterval returns DSM token rotation interval.
 func (c *AppConfig) TokenRefreshInterval() time.Duration {
 	return time.Duration(c.DSM.TokenRefreshHours) * time.Hour
@@ -6,13 +6,16 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/rs/zerolog"
 
 	"zckyachmd/lifeline/internal/auth"
+	"zckyachmd/lifeline/internal/jobs"
 	"zckyachmd/lifeline/internal/mode"
 	"zckyachmd/lifeline/internal/security/audit"
 	"zckyachmd/lifeline/internal/security/confirm"
@@ -20,6 +23,9 @@ import (
 	"zckyachmd/lifeline/internal/services"
 )
 
+// maxMessageRunes keeps replies under Telegram's 4096 character limit.
+const maxMessageRunes = 4000
+
 // Bot wires Telegram updates with services.
 type Bot struct {
 	api        *tgbotapi.BotAPI
@@ -32,29 +38,57 @@ type Bot struct {
 	files      *services.FileService
 	system     *services.SystemService
 	snapshot   *services.SnapshotService
+	runbooks   *services.RunbookService
+	probes     *services.ProbeService
+	runState   *services.RunMarker
 	logger     zerolog.Logger
 	sandbox    string
 	confirmTTL time.Duration
 	pollWait   int
+	jobs       *jobs.Registry
+}
+
+// Options wires the bot with its services and settings.
+type Options struct {
+	Auth       *auth.Authorizer
+	Limiter    *rl.Limiter
+	Confirm    *confirm.Manager
+	Modes      *mode.Manager
+	Audit      *audit.Logger
+	Monitor    *services.MonitoringService
+	Files      *services.FileService
+	System     *services.SystemService
+	Snapshot   *services.SnapshotService
+	Runbooks   *services.RunbookService
+	Probes     *services.ProbeService
+	RunState   *services.RunMarker
+	Logger     zerolog.Logger
+	Sandbox    string
+	ConfirmTTL time.Duration
+	PollWait   int
 }
 
 // New constructs bot handler.
-func New(api *tgbotapi.BotAPI, authz *auth.Authorizer, limiter *rl.Limiter, confirmMgr *confirm.Manager, modes *mode.Manager, auditLog *audit.Logger, monitor *services.MonitoringService, files *services.FileService, sys *services.SystemService, snap *services.SnapshotService, logger zerolog.Logger, sandbox string, confirmTTL time.Duration, pollWait int) *Bot {
+func New(api *tgbotapi.BotAPI, o Options) *Bot {
 	return &Bot{
 		api:        api,
-		auth:       authz,
-		limiter:    limiter,
-		confirm:    confirmMgr,
-		modes:      modes,
-		audit:      auditLog,
-		monitor:    monitor,
-		files:      files,
-		system:     sys,
-		snapshot:   snap,
-		logger:     logger,
-		sandbox:    sandbox,
-		confirmTTL: confirmTTL,
-		pollWait:   pollWait,
+		auth:       o.Auth,
+		limiter:    o.Limiter,
+		confirm:    o.Confirm,
+		modes:      o.Modes,
+		audit:      o.Audit,
+		monitor:    o.Monitor,
+		files:      o.Files,
+		system:     o.System,
+		snapshot:   o.Snapshot,
+		runbooks:   o.Runbooks,
+		probes:     o.Probes,
+		runState:   o.RunState,
+		logger:     o.Logger,
+		sandbox:    o.Sandbox,
+		confirmTTL: o.ConfirmTTL,
+		pollWait:   o.PollWait,
+		jobs:       jobs.New(),
 	}
 }
 
@@ -147,6 +181,20 @@ func (b *Bot) routeCommand(ctx context.Context, m *tgbotapi.Message) {
 	case "ip":
 		out, err := b.monitor.PublicIP(ctx)
 		b.respond(m, cmd, out, err, false)
+	case "ts":
+		// sensitive: may carry a login URL when the node is logged out
+		out, err := b.monitor.Tailscale(ctx)
+		b.respond(m, cmd, out, err, true)
+	case "cf":
+		// sensitive: reconnect reasons quote tunnel logs
+		out, err := b.monitor.Cloudflared(ctx)
+		b.respond(m, cmd, out, err, true)
+	case "kernel":
+		out, err := b.monitor.Kernel(ctx)
+		b.respond(m, cmd, out, err, false)
+	case "probe":
+		out, err := b.probes.Report(ctx, first(args))
+		b.respond(m, cmd, out, err, false)
 	case "diag":
 		if len(args) == 0 {
 			b.reply(m.Chat.ID, "Usage: /diag <net|time>", 0)
@@ -171,8 +219,114 @@ func (b *Bot) routeCommand(ctx context.Context, m *tgbotapi.Message) {
 			b.reply(m.Chat.ID, "Service not allowed", 0)
 			return
 		}
-		out, err := b.system.TailLogs(ctx, args[0], 100)
+		q, err := services.ParseLogQuery(args[1:])
+		if err != nil {
+			b.reply(m.Chat.ID, fmt.Sprintf("%v\nUsage: /logs <svc> [--since 15m] [--lines N] [--grep pattern] [--level warn]", err), 0)
+			return
+		}
+		out, err := b.system.QueryLogs(ctx, args[0], q)
 		b.respond(m, cmd, out, err, true)
+	case "dsm":
+		if len(args) == 0 || args[0] != "ddns" {
+			b.reply(m.Chat.ID, "Usage: /dsm ddns [update]", 0)
+			return
+		}
+		if len(args) > 1 {
+			if args[1] != "update" {
+				b.reply(m.Chat.ID, "Usage: /dsm ddns [update]", 0)
+				return
+			}
+			if !b.requireMode(m, mode.Emergency) {
+				return
+			}
+			b.issueConfirm(m, cmd, args[:2], false)
+			return
+		}
+		out, err := b.monitor.DDNS(ctx)
+		b.respond(m, cmd, out, err, false)
+	case "pkg":
+		if len(args) == 0 {
+			b.reply(m.Chat.ID, "Usage: /pkg list | /pkg restart <name>", 0)
+			return
+		}
+		switch args[0] {
+		case "list":
+			out, err := b.monitor.PackageList(ctx)
+			b.respond(m, cmd, out, err, false)
+		case "restart":
+			if !b.requireMode(m, mode.Emergency) {
+				return
+			}
+			if len(args) < 2 {
+				b.reply(m.Chat.ID, "Usage: /pkg restart <name>", 0)
+				return
+			}
+			if !b.system.IsAllowedPackage(args[1]) {
+				b.reply(m.Chat.ID, "Package not allowed", 0)
+				return
+			}
+			b.issueConfirm(m, cmd, args[:2], false)
+		default:
+			b.reply(m.Chat.ID, "Usage: /pkg list | /pkg restart <name>", 0)
+		}
+	case "storage":
+		if len(args) > 0 {
+			if args[0] != "smart" || len(args) < 2 {
+				b.reply(m.Chat.ID, "Usage: /storage [smart <disk>]", 0)
+				return
+			}
+			out, err := b.monitor.Smart(ctx, args[1])
+			b.respond(m, cmd, out, err, false)
+			return
+		}
+		out, err := b.monitor.Storage(ctx)
+		b.respond(m, cmd, out, err, false)
+	case "backups":
+		out, err := b.monitor.Backups(ctx)
+		b.respond(m, cmd, out, err, false)
+	case "ups":
+		out, err := b.monitor.UPSReport(ctx)
+		b.respond(m, cmd, out, err, false)
+	case "containers":
+		if len(args) > 0 {
+			out, err := b.monitor.ContainerDetail(ctx, args[0])
+			b.respond(m, cmd, out, err, false)
+			return
+		}
+		out, err := b.monitor.ContainersReport(ctx)
+		b.respond(m, cmd, out, err, false)
+	case "dstats":
+		by, top, errMsg := parseSortTop(args)
+		if errMsg != "" {
+			b.reply(m.Chat.ID, errMsg, 0)
+			return
+		}
+		out, err := b.monitor.DStats(ctx, by, top)
+		b.respond(m, cmd, out, err, false)
+	case "top":
+		by, top, errMsg := parseSortTop(args)
+		if errMsg != "" {
+			b.reply(m.Chat.ID, errMsg, 0)
+			return
+		}
+		out, err := b.monitor.Top(ctx, by, top)
+		b.respond(m, cmd, out, err, false)
+	case "du":
+		if len(args) == 0 {
+			b.reply(m.Chat.ID, fmt.Sprintf("Usage: /du <%s> [depth]", strings.Join(b.monitor.DUAliases(), "|")), 0)
+			return
+		}
+		depth := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				b.reply(m.Chat.ID, "depth must be a number (1-3)", 0)
+				return
+			}
+			depth = n
+		}
+		out, err := b.monitor.DiskUsage(ctx, args[0], depth)
+		b.respond(m, cmd, out, err, false)
 	case "ls":
 		path := "."
 		if len(args) > 0 {
@@ -203,29 +357,11 @@ func (b *Bot) routeCommand(ctx context.Context, m *tgbotapi.Message) {
 		}
 		b.audit.Write(m.From.ID, "/get", "ok", map[string]string{"path": args[0]})
 	case "snapshot":
-		buf, err := b.snapshot.Build(ctx)
-		if err != nil {
-			b.respond(m, cmd, "", err, false)
-			return
-		}
-		filePath, err := b.snapshot.Save(buf, filepath.Join(b.sandbox, "snapshots"))
-		if err != nil {
-			b.respond(m, cmd, "", err, false)
-			return
-		}
-		f, err := os.Open(filePath)
-		if err != nil {
-			b.respond(m, cmd, "", err, false)
-			return
-		}
-		defer f.Close()
-		doc := tgbotapi.NewDocument(m.Chat.ID, tgbotapi.FileReader{Name: filepath.Base(filePath), Reader: f})
-		if _, err := b.api.Send(doc); err != nil {
+		if err := b.sendSnapshot(ctx, m.Chat.ID); err != nil {
 			b.respond(m, cmd, "", err, false)
 			return
 		}
 		b.audit.Write(m.From.ID, "/snapshot", "ok", nil)
-		go func() { _ = os.Remove(filePath) }()
 	case "restart":
 		if !b.requireMode(m, mode.Emergency) {
 			return
@@ -234,17 +370,34 @@ func (b *Bot) routeCommand(ctx context.Context, m *tgbotapi.Message) {
 			b.reply(m.Chat.ID, "Usage: /restart <service>", 0)
 			return
 		}
-		b.issueConfirm(m, cmd, args, false)
+		if !b.system.IsRestartable(args[0]) {
+			b.reply(m.Chat.ID, "Service not allowed", 0)
+			return
+		}
+		b.issueConfirm(m, cmd, args[:1], false)
 	case "cleanup":
 		if !b.requireMode(m, mode.Emergency) {
 			return
 		}
-		b.issueConfirm(m, cmd, args, false)
+		if len(args) > 0 && args[0] == "logs" {
+			b.issueLogCleanup(ctx, m, args[1:])
+			return
+		}
+		if len(args) == 0 || !services.IsCleanupScope(args[0]) {
+			b.reply(m.Chat.ID, "Usage: /cleanup <images|containers|builder|networks|logs>", 0)
+			return
+		}
+		preview, err := b.system.CleanupPreview(ctx, args[0])
+		if err != nil {
+			b.respond(m, cmd, "", err, false)
+			return
+		}
+		b.issueConfirmNote(m, cmd, args[:1], false, preview.String())
 	case "reboot":
 		if !b.requireMode(m, mode.Emergency) {
 			return
 		}
-		b.issueConfirm(m, cmd, args, true)
+		b.issueReboot(ctx, m, args)
 	case "apply":
 		if !b.requireMode(m, mode.Emergency) {
 			return
@@ -254,8 +407,21 @@ func (b *Bot) routeCommand(ctx context.Context, m *tgbotapi.Message) {
 			return
 		}
 		b.issueConfirm(m, cmd, args, false)
+	case "runbook":
+		b.handleRunbook(m, args)
+	case "wake":
+		b.handleWake(m, args)
+	case "follow":
+		b.handleFollow(ctx, m, args)
+	case "cancel":
+		if !b.jobs.Cancel(m.Chat.ID, jobs.ErrCancelled) {
+			b.reply(m.Chat.ID, "Nothing to cancel.", 0)
+			return
+		}
+		b.audit.Write(m.From.ID, "/cancel", "ok", nil)
 	case "lockdown":
 		b.modes.Set(mode.Lockdown)
+		b.jobs.CancelAll(jobs.ErrLockdown)
 		b.reply(m.Chat.ID, "Lockdown enabled. Destructive commands disabled.", 0)
 		b.audit.Write(m.From.ID, "/lockdown", "ok", nil)
 	case "unlock":
@@ -301,13 +467,78 @@ func (b *Bot) handleUpload(ctx context.Context, m *tgbotapi.Message) {
 	b.audit.Write(m.From.ID, "upload", "ok", map[string]string{"file": file.FileName})
 }
 
+// sendSnapshot builds a diagnostics snapshot, sends it and removes the local copy.
+func (b *Bot) sendSnapshot(ctx context.Context, chatID int64) error {
+	buf, err := b.snapshot.Build(ctx)
+	if err != nil {
+		return err
+	}
+	filePath, err := b.snapshot.Save(buf, filepath.Join(b.sandbox, "snapshots"))
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(filePath)
+	defer f.Close()
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileReader{Name: filepath.Base(filePath), Reader: f})
+	_, err = b.api.Send(doc)
+	return err
+}
+
 func (b *Bot) issueConfirm(m *tgbotapi.Message, cmd string, args []string, double bool) {
+	b.issueConfirmNote(m, cmd, args, double, "")
+}
+
+// issueConfirmNote issues a token and prefixes the prompt with a warning/preview.
+func (b *Bot) issueConfirmNote(m *tgbotapi.Message, cmd string, args []string, double bool, note string) {
 	token, pa := b.confirm.Issue(m.From.ID, cmd, args, double)
-	b.reply(m.Chat.ID, fmt.Sprintf("Confirm with /confirm %s (ttl %s)", token, b.confirmTTL), 0)
+	text := fmt.Sprintf("Confirm with /confirm %s (ttl %s)", token, b.confirmTTL)
+	if note != "" {
+		text = note + "\n\n" + text
+	}
+	b.reply(m.Chat.ID, text, 0)
 	b.audit.Write(m.From.ID, "/"+cmd, "pending", map[string]string{"token": token, "args": strings.Join(args, ",")})
 	_ = pa
 }
 
+// issueLogCleanup previews log candidates and binds the selected paths to a token.
+// Args: [all|1,3,...] [truncate|gzip].
+func (b *Bot) issueLogCleanup(ctx context.Context, m *tgbotapi.Message, args []string) {
+	cands := b.system.LogCleanupCandidates(ctx)
+	how := "truncate"
+	selected := cands
+	for _, a := range args {
+		switch a {
+		case "truncate", "gzip":
+			how = a
+		case "all":
+			selected = cands
+		default:
+			selected = nil
+			for _, part := range strings.Split(a, ",") {
+				n, err := strconv.Atoi(part)
+				if err != nil || n < 1 || n > len(cands) {
+					b.reply(m.Chat.ID, "Usage: /cleanup logs [all|1,2,...] [truncate|gzip]", 0)
+					return
+				}
+				selected = append(selected, cands[n-1])
+			}
+		}
+	}
+	if len(selected) == 0 {
+		b.reply(m.Chat.ID, services.LogCleanupPreview(nil, how), 0)
+		return
+	}
+	tokenArgs := []string{"logs", how}
+	for _, c := range selected {
+		tokenArgs = append(tokenArgs, c.Path)
+	}
+	b.issueConfirmNote(m, "cleanup", tokenArgs, false, services.LogCleanupPreview(selected, how))
+}
+
 func (b *Bot) handleConfirm(ctx context.Context, m *tgbotapi.Message, args []string) {
 	if len(args) == 0 {
 		b.reply(m.Chat.ID, "Usage: /confirm <token>", 0)
@@ -327,14 +558,41 @@ func (b *Bot) handleConfirm(ctx context.Context, m *tgbotapi.Message, args []str
 
 	switch pa.Command {
 	case "restart":
-		out, err := b.system.RestartService(ctx, first(pa.Args))
+		b.restartAndVerify(ctx, m, first(pa.Args))
+	case "dsm":
+		if len(pa.Args) < 2 || pa.Args[0] != "ddns" || pa.Args[1] != "update" {
+			b.reply(m.Chat.ID, "Unknown token command", 0)
+			return
+		}
+		out, err := b.system.UpdateDDNS(ctx)
 		b.respond(m, pa.Command, out, err, false)
+	case "pkg":
+		if len(pa.Args) < 2 || pa.Args[0] != "restart" {
+			b.reply(m.Chat.ID, "Unknown token command", 0)
+			return
+		}
+		b.startPackageRestart(ctx, m, pa.Args[1])
 	case "cleanup":
-		out, err := b.system.Cleanup(ctx)
+		if first(pa.Args) == "logs" && len(pa.Args) > 1 {
+			b.startLogCleanup(ctx, m, pa.Args[1], pa.Args[2:])
+			return
+		}
+		out, err := b.system.Cleanup(ctx, first(pa.Args))
 		b.respond(m, pa.Command, out, err, false)
 	case "reboot":
-		out, err := b.system.Reboot(ctx)
-		b.respond(m, pa.Command, out, err, true)
+		b.scheduleReboot(ctx, m, pa.Args)
+	case "runbook":
+		if len(pa.Args) < 2 || pa.Args[0] != "run" {
+			b.reply(m.Chat.ID, "Unknown token command", 0)
+			return
+		}
+		b.startRunbook(ctx, m, pa.Args[1])
+	case "wake":
+		if !b.system.IsWakeAlias(first(pa.Args)) {
+			b.reply(m.Chat.ID, "Unknown token command", 0)
+			return
+		}
+		b.startWake(ctx, m, pa.Args[0])
 	case "apply":
 		if len(pa.Args) == 0 {
 			b.reply(m.Chat.ID, "apply requires filename", 0)
@@ -371,8 +629,30 @@ func (b *Bot) respond(m *tgbotapi.Message, cmd string, out string, err error, se
 	}
 }
 
+// Notify sends an unsolicited message (alerts) to every admin chat.
+func (b *Bot) Notify(text string) {
+	for _, id := range b.auth.IDs() {
+		b.reply(id, text, 0)
+	}
+}
+
 func (b *Bot) reply(chatID int64, text string, ttl time.Duration) *tgbotapi.Message {
-	msg := tgbotapi.NewMessage(chatID, text)
+	var msg tgbotapi.Chattable = tgbotapi.NewMessage(chatID, text)
+	if utf8.RuneCountInString(text) > maxMessageRunes {
+		// too long for a text message; send as a document instead of failing
+		return b.sendDocument(chatID, fmt.Sprintf("output-%d.txt", time.Now().Unix()), []byte(text), "Output too long, attached as file.", ttl)
+	}
+	return b.send(chatID, msg, ttl)
+}
+
+// sendDocument uploads data as a file attachment.
+func (b *Bot) sendDocument(chatID int64, name string, data []byte, caption string, ttl time.Duration) *tgbotapi.Message {
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name, Bytes: data})
+	doc.Caption = caption
+	return b.send(chatID, doc, ttl)
+}
+
+func (b *Bot) send(chatID int64, msg tgbotapi.Chattable, ttl time.Duration) *tgbotapi.Message {
 	sent, err := b.api.Send(msg)
 	if err != nil {
 		b.logger.Error().Err(err).Msg("send message failed")
@@ -401,16 +681,62 @@ func (b *Bot) requireMode(m *tgbotapi.Message, required mode.Mode) bool {
 
 func helpText() string {
 	return "LIFELINE commands:\n" +
-		"/health /status /resources /ip\n" +
-		"/diag net|time /logs <svc>\n" +
+		"/health /status /resources /ip /ts /cf\n" +
+		"/storage [smart <disk>] /backups /ups\n" +
+		"/containers [name] /dstats [cpu|mem|net|io] [N]\n" +
+		"/top [cpu|mem] [N]\n" +
+		"/du <alias> [depth]\n" +
+		"/diag net|time /probe [name] /kernel\n" +
+		"/logs <svc> [--since 15m] [--lines N] [--grep re] [--level warn]\n" +
+		"/follow <svc> [minutes] /cancel\n" +
+		"/dsm ddns [update] /pkg list\n" +
 		"/ls [path] /get <path> /snapshot\n" +
-		"/restart <svc> /cleanup /reboot (confirm)\n" +
+		"/restart <svc> /pkg restart <name>\n" +
+		"/cleanup <scope> /reboot [force] (confirm)\n" +
+		"/runbook list|run <name>\n" +
+		"/wake <alias> (confirm)\n" +
 		"/lockdown /unlock /mode"
 }
 
+// parseSortTop reads the "[key] [N]" arguments of /dstats and /top; the key
+// defaults to cpu and N to 10. errMsg is set when N is out of range.
+func parseSortTop(args []string) (by string, n int, errMsg string) {
+	by, n = "cpu", 10
+	for _, a := range args {
+		if v, err := strconv.Atoi(a); err == nil {
+			if v <= 0 || v > 50 {
+				return "", 0, "N must be 1-50"
+			}
+			n = v
+			continue
+		}
+		by = strings.ToLower(a)
+	}
+	return by, n, ""
+}
+
 func first(args []string) string {
 	if len(args) == 0 {
 		return ""
 	}
 	return args[0]
 }
+
+// AnnounceStartup tells every admin the bot is back, how the previous run
+// ended and a compact service status.
+func (b *Bot) AnnounceStartup(ctx context.Context, version string, prev services.RunState, hadPrev bool) {
+	var bootTime time.Time
+	uptime := "unknown"
+	if up, err := services.HostUptime(); err == nil {
+		bootTime = time.Now().Add(-up)
+		uptime = up.Round(time.Second).String()
+	}
+	lines := []string{
+		fmt.Sprintf("LIFELINE %s started", version),
+		"host uptime: " + uptime,
+		fmt.Sprintf("mode: %s", b.modes.Current()),
+		"previous run: " + services.DescribePreviousRun(prev, hadPrev, bootTime),
+		"status: " + b.monitor.StatusSummary(ctx),
+	}
+	b.Notify(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zckyachmd/lifeline/internal/jobs"
+	"zckyachmd/lifeline/internal/mode"
+	"zckyachmd/lifeline/internal/services"
+)
+
+const (
+	followDefault   = 5 * time.Minute
+	followMax       = 30 * time.Minute
+	followEditEvery = 3 * time.Second
+	followWindow    = 30
+	maxTranscript   = 1 << 20
+)
+
+// handleFollow starts a bounded live log view that edits a single message.
+func (b *Bot) handleFollow(ctx context.Context, m *tgbotapi.Message, args []string) {
+	if len(args) == 0 || len(args) > 2 {
+		b.reply(m.Chat.ID, "Usage: /follow <service> [minutes]", 0)
+		return
+	}
+	svc := strings.ToLower(args[0])
+	if !b.system.IsAllowedService(svc) {
+		b.reply(m.Chat.ID, "Service not allowed", 0)
+		return
+	}
+	limit := followDefault
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || time.Duration(n)*time.Minute > followMax {
+			b.reply(m.Chat.ID, fmt.Sprintf("minutes must be 1-%d", int(followMax.Minutes())), 0)
+			return
+		}
+		limit = time.Duration(n) * time.Minute
+	}
+	jobCtx, release, ok := b.jobs.Start(ctx, m.Chat.ID, limit)
+	if !ok {
+		b.reply(m.Chat.ID, "Another live task is running. Use /cancel first.", 0)
+		return
+	}
+	sent := b.reply(m.Chat.ID, fmt.Sprintf("follow %s: waiting for new lines (%s, /cancel to stop)", svc, limit), time.Hour)
+	if sent == nil {
+		release()
+		return
+	}
+	b.audit.Write(m.From.ID, "/follow", "start", map[string]string{"service": svc, "minutes": strconv.Itoa(int(limit.Minutes()))})
+	go b.runFollow(jobCtx, release, m, svc, sent.MessageID)
+}
+
+func (b *Bot) runFollow(ctx context.Context, release func(), m *tgbotapi.Message, svc string, msgID int) {
+	defer release()
+	chatID := m.Chat.ID
+	deadline, _ := ctx.Deadline()
+
+	lines := make(chan string, 256)
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- b.system.FollowLogs(ctx, svc, func(l string) {
+			select {
+			case lines <- l:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	var (
+		window   = services.NewFollowWindow(followWindow, maxTranscript)
+		dirty    bool
+		nextEdit time.Time
+		stopErr  error
+	)
+	ticker := time.NewTicker(followEditEvery)
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case l := <-lines:
+			window.Add(l)
+			dirty = true
+		case <-ticker.C:
+			if b.modes.Current() == mode.Lockdown {
+				b.jobs.Cancel(chatID, jobs.ErrLockdown)
+				continue
+			}
+			if !dirty || time.Now().Before(nextEdit) {
+				continue
+			}
+			left := time.Until(deadline).Round(time.Second)
+			header := fmt.Sprintf("follow %s: %d lines, %s left (/cancel to stop)", svc, window.Total(), left)
+			if wait := b.editText(chatID, msgID, window.Render(header, maxMessageRunes)); wait > 0 {
+				nextEdit = time.Now().Add(wait)
+				continue
+			}
+			dirty = false
+		case err := <-streamErr:
+			stopErr = err
+			break loop
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	for len(lines) > 0 {
+		window.Add(<-lines)
+	}
+
+	reason := jobs.StopReason(ctx)
+	if stopErr != nil {
+		reason = fmt.Sprintf("error: %v", stopErr)
+	}
+	total := window.Total()
+	header := fmt.Sprintf("follow %s stopped (%s): %d lines", svc, reason, total)
+	b.editText(chatID, msgID, window.Render(header, maxMessageRunes))
+	b.audit.Write(m.From.ID, "/follow", "stop", map[string]string{"service": svc, "reason": reason, "lines": strconv.Itoa(total)})
+
+	if total == 0 {
+		return
+	}
+	caption := fmt.Sprintf("follow %s transcript (%d lines)", svc, total)
+	if window.Clipped() {
+		caption += ", clipped at 1 MB"
+	}
+	name := fmt.Sprintf("follow-%s-%s.txt", svc, time.Now().UTC().Format("20060102-150405"))
+	b.sendDocument(chatID, name, []byte(window.Transcript()), caption, time.Hour)
+}
+
+// editText replaces a message's text. It returns how long to back off when
+// Telegram rate-limits edits, zero otherwise.
+func (b *Bot) editText(chatID int64, msgID int, text string) time.Duration {
+	_, err := b.api.Send(tgbotapi.NewEditMessageText(chatID, msgID, text))
+	if err == nil {
+		return 0
+	}
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return time.Duration(apiErr.RetryAfter) * time.Second
+	}
+	if !strings.Contains(err.Error(), "message is not modified") {
+		b.logger.Warn().Err(err).Msg("edit message failed")
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zckyachmd/lifeline/internal/jobs"
+)
+
+// logCleanupMax bounds a confirmed log cleanup; gzip rotation of large logs
+// can take minutes.
+const logCleanupMax = 30 * time.Minute
+
+// startLogCleanup truncates or rotates the confirmed logs as a cancelable job,
+// so compressing a multi-GB file does not stall /cancel or /lockdown.
+func (b *Bot) startLogCleanup(ctx context.Context, m *tgbotapi.Message, how string, paths []string) {
+	jobCtx, release, ok := b.jobs.Start(ctx, m.Chat.ID, logCleanupMax)
+	if !ok {
+		b.reply(m.Chat.ID, "Another live task is running. Use /cancel first.", 0)
+		return
+	}
+	sent := b.reply(m.Chat.ID, fmt.Sprintf("Cleaning %d logs (%s)... (/cancel aborts)", len(paths), how), 0)
+	go func() {
+		defer release()
+		out, err := b.system.CleanupLogs(jobCtx, how, paths)
+		status := "ok"
+		text := out
+		switch {
+		case err != nil:
+			status = "error"
+			text = err.Error()
+		case jobCtx.Err() != nil:
+			status = "cancelled"
+			text = fmt.Sprintf("%s\nLog cleanup aborted (%s).", out, jobs.StopReason(jobCtx))
+		}
+		if sent == nil || b.editText(m.Chat.ID, sent.MessageID, text) > 0 {
+			b.reply(m.Chat.ID, text, 0)
+		}
+		b.audit.Write(m.From.ID, "/cleanup", status, map[string]string{"mode": how, "files": fmt.Sprint(len(paths))})
+	}()
+}
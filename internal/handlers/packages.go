@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zckyachmd/lifeline/internal/jobs"
+)
+
+// startPackageRestart restarts a confirmed package as a cancelable job, since
+// waiting for it to run again can take up to the verify timeout.
+func (b *Bot) startPackageRestart(ctx context.Context, m *tgbotapi.Message, id string) {
+	jobCtx, release, ok := b.jobs.Start(ctx, m.Chat.ID, b.system.PackageRestartLimit())
+	if !ok {
+		b.reply(m.Chat.ID, "Another live task is running. Use /cancel first.", 0)
+		return
+	}
+	sent := b.reply(m.Chat.ID, fmt.Sprintf("Restarting package %s... (/cancel aborts)", id), 0)
+	go func() {
+		defer release()
+		out, err := b.system.RestartPackage(jobCtx, id)
+		status := "ok"
+		text := out
+		if err != nil {
+			status = "error"
+			text = err.Error()
+			if jobCtx.Err() != nil {
+				status = "cancelled"
+				text = fmt.Sprintf("package %s restart aborted (%s)", id, jobs.StopReason(jobCtx))
+			}
+		}
+		if sent == nil || b.editText(m.Chat.ID, sent.MessageID, text) > 0 {
+			b.reply(m.Chat.ID, text, 0)
+		}
+		b.audit.Write(m.From.ID, "/pkg", status, map[string]string{"package": id})
+	}()
+}
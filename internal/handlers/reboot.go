@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zckyachmd/lifeline/internal/jobs"
+)
+
+const (
+	rebootCountdown   = 60 * time.Second
+	rebootTick        = 10 * time.Second
+	rebootSnapshotMax = 2 * time.Minute
+	// rebootSlack covers the final pre-flight and the reboot call itself.
+	rebootSlack = time.Minute
+)
+
+// issueReboot runs pre-flight checks and asks for double confirmation.
+// Failing checks refuse the reboot unless "force" is given.
+func (b *Bot) issueReboot(ctx context.Context, m *tgbotapi.Message, args []string) {
+	pf := b.monitor.RebootPreflight(ctx)
+	if pf.Blocked() && first(args) != "force" {
+		b.reply(m.Chat.ID, pf.String()+"\n\nReboot refused. Resolve the failures or use /reboot force.", 0)
+		b.audit.Write(m.From.ID, "/reboot", "deny", map[string]string{"reason": "preflight"})
+		return
+	}
+	note := pf.String() + fmt.Sprintf("\n\nAfter confirmation a snapshot is sent and the host reboots in %s (/cancel aborts).", rebootCountdown)
+	b.issueConfirmNote(m, "reboot", args, true, note)
+}
+
+// scheduleReboot re-runs the pre-flight at confirmation time and starts the
+// snapshot + countdown job. A blocked pre-flight refuses unless forced.
+func (b *Bot) scheduleReboot(ctx context.Context, m *tgbotapi.Message, args []string) {
+	force := first(args) == "force"
+	if !b.rebootPreflightOK(ctx, m, force) {
+		return
+	}
+	jobCtx, release, ok := b.jobs.Start(ctx, m.Chat.ID, rebootSnapshotMax+rebootCountdown+rebootSlack)
+	if !ok {
+		b.reply(m.Chat.ID, "Another live task is running. Use /cancel first.", 0)
+		return
+	}
+	b.logRunState(b.runState.MarkRebootScheduled())
+	b.audit.Write(m.From.ID, "/reboot", "scheduled", map[string]string{"force": fmt.Sprint(force)})
+	go b.runReboot(jobCtx, release, m, force)
+}
+
+// rebootPreflightOK runs the pre-flight checks and reports whether the reboot
+// may proceed, replying with the report when it may not.
+func (b *Bot) rebootPreflightOK(ctx context.Context, m *tgbotapi.Message, force bool) bool {
+	pf := b.monitor.RebootPreflight(ctx)
+	if !pf.Blocked() || force {
+		return true
+	}
+	b.reply(m.Chat.ID, pf.String()+"\n\nReboot refused. Resolve the failures or use /reboot force.", 0)
+	b.audit.Write(m.From.ID, "/reboot", "deny", map[string]string{"reason": "preflight"})
+	return false
+}
+
+func (b *Bot) runReboot(ctx context.Context, release func(), m *tgbotapi.Message, force bool) {
+	defer release()
+	chatID := m.Chat.ID
+	sent := b.reply(chatID, "Reboot scheduled. Building snapshot...", 0)
+
+	snapCtx, cancel := context.WithTimeout(ctx, rebootSnapshotMax)
+	err := b.sendSnapshot(snapCtx, chatID)
+	cancel()
+	if err != nil {
+		b.reply(chatID, fmt.Sprintf("Snapshot failed: %v (continuing)", err), 0)
+	}
+
+	executeAt := time.Now().Add(rebootCountdown)
+	status := func(text string) {
+		if sent != nil {
+			b.editText(chatID, sent.MessageID, text)
+		} else {
+			b.reply(chatID, text, 0)
+		}
+	}
+
+	ticker := time.NewTicker(rebootTick)
+	defer ticker.Stop()
+	for {
+		left := time.Until(executeAt).Round(time.Second)
+		if left <= 0 {
+			break
+		}
+		status(fmt.Sprintf("Rebooting in %s. /cancel to abort.", left))
+		select {
+		case <-ctx.Done():
+			reason := jobs.StopReason(ctx)
+			b.logRunState(b.runState.MarkRunning())
+			status(fmt.Sprintf("Reboot aborted (%s).", reason))
+			b.audit.Write(m.From.ID, "/reboot", "cancelled", map[string]string{"reason": reason})
+			return
+		case <-ticker.C:
+		case <-time.After(time.Until(executeAt)):
+		}
+	}
+
+	// conditions may have changed during the snapshot and countdown
+	if !b.rebootPreflightOK(ctx, m, force) {
+		b.logRunState(b.runState.MarkRunning())
+		status("Reboot aborted (pre-flight failed).")
+		return
+	}
+	b.logRunState(b.runState.MarkRebooting())
+	status("Rebooting now.")
+	out, err := b.system.Reboot(ctx)
+	if err != nil {
+		// the host is staying up; a later clean stop must be recorded as such
+		b.logRunState(b.runState.MarkRunning())
+	}
+	b.respond(m, "reboot", out, err, true)
+}
+
+func (b *Bot) logRunState(err error) {
+	if err != nil {
+		b.logger.Error().Err(err).Msg("write run state failed")
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zckyachmd/lifeline/internal/jobs"
+	"zckyachmd/lifeline/internal/mode"
+)
+
+const (
+	runbookMax       = 30 * time.Minute
+	runbookEditEvery = 2 * time.Second
+)
+
+// handleRunbook serves /runbook list and /runbook run <name>.
+func (b *Bot) handleRunbook(m *tgbotapi.Message, args []string) {
+	switch first(args) {
+	case "list":
+		b.reply(m.Chat.ID, b.runbooks.List(), 0)
+		b.audit.Write(m.From.ID, "/runbook", "ok", nil)
+	case "run":
+		if !b.requireMode(m, mode.Emergency) {
+			return
+		}
+		if len(args) < 2 {
+			b.reply(m.Chat.ID, "Usage: /runbook run <name>", 0)
+			return
+		}
+		plan, err := b.runbooks.Plan(args[1])
+		if err != nil {
+			b.reply(m.Chat.ID, err.Error(), 0)
+			return
+		}
+		note := plan + "\n\nStops at the first failed action or verification; /cancel aborts."
+		b.issueConfirmNote(m, "runbook", []string{"run", args[1]}, false, note)
+	default:
+		b.reply(m.Chat.ID, "Usage: /runbook list | /runbook run <name>", 0)
+	}
+}
+
+// startRunbook runs a confirmed runbook as a cancelable job with live progress.
+func (b *Bot) startRunbook(ctx context.Context, m *tgbotapi.Message, name string) {
+	if !b.runbooks.Exists(name) {
+		b.reply(m.Chat.ID, "Unknown runbook", 0)
+		return
+	}
+	jobCtx, release, ok := b.jobs.Start(ctx, m.Chat.ID, runbookMax)
+	if !ok {
+		b.reply(m.Chat.ID, "Another live task is running. Use /cancel first.", 0)
+		return
+	}
+	sent := b.reply(m.Chat.ID, fmt.Sprintf("Runbook %s starting...", name), 0)
+	b.audit.Write(m.From.ID, "/runbook", "start", map[string]string{"runbook": name})
+	go func() {
+		defer release()
+		var lastEdit time.Time
+		out, err := b.runbooks.Run(jobCtx, name, func(transcript string) {
+			if sent == nil || time.Since(lastEdit) < runbookEditEvery {
+				return
+			}
+			lastEdit = time.Now()
+			b.editText(m.Chat.ID, sent.MessageID, clipMiddle(transcript, maxMessageRunes))
+		})
+		status := "ok"
+		if err != nil {
+			status = "error"
+			reason := err.Error()
+			if jobCtx.Err() != nil {
+				reason = "aborted: " + jobs.StopReason(jobCtx)
+			}
+			out += "\n" + reason
+		}
+		text := clipMiddle(out, maxMessageRunes)
+		if sent == nil || b.editText(m.Chat.ID, sent.MessageID, text) > 0 {
+			b.reply(m.Chat.ID, text, 0)
+		}
+		b.audit.Write(m.From.ID, "/runbook", status, map[string]string{"runbook": name})
+	}()
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zckyachmd/lifeline/internal/jobs"
+)
+
+// restartAndVerify restarts a service and, when it has a health check,
+// polls it until healthy or the verify timeout passes. Both run as one
+// cancelable job that edits the reply live.
+func (b *Bot) restartAndVerify(ctx context.Context, m *tgbotapi.Message, svc string) {
+	jobCtx, release, ok := b.jobs.Start(ctx, m.Chat.ID, b.system.RestartLimit(svc))
+	if !ok {
+		b.reply(m.Chat.ID, "Another live task is running. Use /cancel first.", 0)
+		return
+	}
+	sent := b.reply(m.Chat.ID, fmt.Sprintf("Restarting %s... (/cancel aborts)", svc), 0)
+	go func() {
+		defer release()
+		text, status, elapsed := b.runRestart(jobCtx, m.Chat.ID, sent, svc)
+		text = clipMiddle(text, maxMessageRunes)
+		if sent == nil || b.editText(m.Chat.ID, sent.MessageID, text) > 0 {
+			b.reply(m.Chat.ID, text, 0)
+		}
+		b.audit.Write(m.From.ID, "/restart", status, map[string]string{"service": svc, "elapsed": elapsed.String()})
+	}()
+}
+
+// runRestart performs the restart and verification and returns the final
+// reply text, audit status and verification time.
+func (b *Bot) runRestart(ctx context.Context, chatID int64, sent *tgbotapi.Message, svc string) (string, string, time.Duration) {
+	out, err := b.system.RestartService(ctx, svc)
+	head := strings.TrimSpace(out)
+	if err != nil {
+		if ctx.Err() != nil {
+			return strings.TrimSpace(head + "\nrestart aborted: " + jobs.StopReason(ctx)), "cancelled", 0
+		}
+		return strings.TrimSpace(head + "\n" + err.Error()), "error", 0
+	}
+	if head == "" {
+		head = svc + " restarted"
+	}
+	if !b.system.CanVerify(svc) {
+		return head, "ok", 0
+	}
+	if sent != nil {
+		b.editText(chatID, sent.MessageID, head+"\nverifying...")
+	}
+	res := b.system.VerifyRestart(ctx, svc, func(detail string, elapsed time.Duration) {
+		if sent != nil {
+			b.editText(chatID, sent.MessageID, fmt.Sprintf("%s\nverifying (%s): %s", head, elapsed, detail))
+		}
+	})
+	switch {
+	case res.Healthy:
+		return fmt.Sprintf("%s\nhealthy after %s: %s", head, res.Elapsed, res.Detail), "ok", res.Elapsed
+	case ctx.Err() != nil:
+		return fmt.Sprintf("%s\nverification aborted after %s (%s): %s", head, res.Elapsed, jobs.StopReason(ctx), res.Detail), "cancelled", res.Elapsed
+	}
+	text := fmt.Sprintf("%s\nNOT healthy after %s: %s", head, res.Elapsed, res.Detail)
+	if res.Logs != "" {
+		text += "\n\nlast log lines:\n" + strings.TrimSpace(res.Logs)
+	}
+	return text, "unhealthy", res.Elapsed
+}
+
+// clipMiddle trims text to n runes, keeping its start and the (usually more useful) end.
+func clipMiddle(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	r := []rune(text)
+	const marker = "\n...\n"
+	keep := n - len(marker)
+	head := keep / 3
+	return string(r[:head]) + marker + string(r[len(r)-(keep-head):])
+}
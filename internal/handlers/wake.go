@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"zckyachmd/lifeline/internal/jobs"
+	"zckyachmd/lifeline/internal/mode"
+)
+
+const wakeEditEvery = 10 * time.Second
+
+// handleWake validates /wake <alias> and asks for confirmation.
+func (b *Bot) handleWake(m *tgbotapi.Message, args []string) {
+	aliases := b.system.WakeAliases()
+	if len(aliases) == 0 {
+		b.reply(m.Chat.ID, "No wake hosts configured (wake.hosts)", 0)
+		return
+	}
+	if len(args) == 0 {
+		b.reply(m.Chat.ID, fmt.Sprintf("Usage: /wake <%s>", strings.Join(aliases, "|")), 0)
+		return
+	}
+	if !b.system.IsWakeAlias(args[0]) {
+		b.reply(m.Chat.ID, "Wake host not allowed", 0)
+		return
+	}
+	if !b.requireMode(m, mode.Emergency) {
+		return
+	}
+	b.issueConfirm(m, "wake", args[:1], false)
+}
+
+// startWake sends the magic packet and, when the host has a check, polls
+// it as a cancelable job, editing the reply until it answers or times out.
+func (b *Bot) startWake(ctx context.Context, m *tgbotapi.Message, alias string) {
+	out, err := b.system.Wake(ctx, alias)
+	if err != nil || b.system.WakeCheck(alias) == "" {
+		b.respond(m, "wake", out, err, false)
+		return
+	}
+	jobCtx, release, ok := b.jobs.Start(ctx, m.Chat.ID, b.system.WakePoll()+time.Minute)
+	if !ok {
+		b.respond(m, "wake", out+"\nNot polling: another live task is running.", nil, false)
+		return
+	}
+	check := b.system.WakeCheck(alias)
+	sent := b.reply(m.Chat.ID, fmt.Sprintf("%s\nWaiting for %s (%s)...", out, alias, check), 0)
+	go func() {
+		defer release()
+		var lastEdit time.Time
+		up, elapsed, err := b.system.WaitReachable(jobCtx, alias, func(elapsed time.Duration) {
+			if sent == nil || time.Since(lastEdit) < wakeEditEvery {
+				return
+			}
+			lastEdit = time.Now()
+			b.editText(m.Chat.ID, sent.MessageID, fmt.Sprintf("%s\nWaiting for %s (%s)... %s", out, alias, check, elapsed))
+		})
+		status := "ok"
+		var result string
+		switch {
+		case err != nil:
+			status = "error"
+			result = "Polling aborted: " + jobs.StopReason(jobCtx)
+		case up:
+			result = fmt.Sprintf("%s is up after %s", alias, elapsed)
+		default:
+			status = "error"
+			result = fmt.Sprintf("%s not reachable after %s", alias, elapsed)
+		}
+		text := out + "\n" + result
+		if sent == nil || b.editText(m.Chat.ID, sent.MessageID, text) > 0 {
+			b.reply(m.Chat.ID, text, 0)
+		}
+		b.audit.Write(m.From.ID, "/wake", status, map[string]string{"host": alias})
+	}()
+}
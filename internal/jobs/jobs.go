@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Stop causes reported by StopReason.
+var (
+	ErrCancelled = errors.New("cancelled")
+	ErrTimeout   = errors.New("timeout")
+	ErrLockdown  = errors.New("lockdown")
+)
+
+// Registry tracks long-running tasks, at most one per chat.
+type Registry struct {
+	mu   sync.Mutex
+	jobs map[int64]context.CancelCauseFunc
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{jobs: make(map[int64]context.CancelCauseFunc)}
+}
+
+// Start registers a task for a chat. The returned context ends on Cancel,
+// CancelAll or after limit; release must be called when the task finishes.
+// ok is false when the chat already runs a task.
+func (r *Registry) Start(parent context.Context, chatID int64, limit time.Duration) (context.Context, func(), bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, busy := r.jobs[chatID]; busy {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	ctx, stop := context.WithTimeoutCause(ctx, limit, ErrTimeout)
+	r.jobs[chatID] = cancel
+	release := func() {
+		stop()
+		cancel(nil)
+		r.mu.Lock()
+		delete(r.jobs, chatID)
+		r.mu.Unlock()
+	}
+	return ctx, release, true
+}
+
+// Cancel stops the task running in a chat, if any.
+func (r *Registry) Cancel(chatID int64, cause error) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cancel, ok := r.jobs[chatID]
+	if ok {
+		cancel(cause)
+	}
+	return ok
+}
+
+// CancelAll stops every running task (used on lockdown).
+func (r *Registry) CancelAll(cause error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cancel := range r.jobs {
+		cancel(cause)
+	}
+}
+
+// StopReason describes why a task context ended.
+func StopReason(ctx context.Context) string {
+	switch cause := context.Cause(ctx); {
+	case cause == nil:
+		return "stream ended"
+	case errors.Is(cause, ErrCancelled), errors.Is(cause, ErrTimeout), errors.Is(cause, ErrLockdown):
+		return cause.Error()
+	default:
+		return "bot shutting down"
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// BackupTask is a Hyper Backup task summary.
+type BackupTask struct {
+	ID         string
+	Name       string
+	Status     string
+	LastResult string
+	LastRun    string
+	NextRun    string
+	Progress   string
+}
+
+// Running reports whether the task is backing up or preparing to.
+func (t BackupTask) Running() bool {
+	switch t.Status {
+	case "backup", "backingup", "detect", "preparing", "waiting", "version_deleting":
+		return true
+	}
+	return false
+}
+
+// Failed reports a non-successful last result.
+func (t BackupTask) Failed() bool {
+	switch t.LastResult {
+	case "", "done", "none", "success":
+		return false
+	}
+	return true
+}
+
+// ParseBackupTasks extracts tasks from a SYNO.Backup.Task list payload.
+func ParseBackupTasks(data map[string]any) []BackupTask {
+	items := asList(data["task_list"])
+	out := make([]BackupTask, 0, len(items))
+	for _, t := range items {
+		task := BackupTask{
+			ID:         asString(t["task_id"]),
+			Name:       asString(t["name"]),
+			Status:     strings.ToLower(asString(t["status"])),
+			LastResult: strings.ToLower(asString(t["last_bkp_result"])),
+			LastRun:    asString(t["last_bkp_time"]),
+			NextRun:    asString(t["next_bkp_time"]),
+		}
+		if prog, ok := t["last_bkp_progress"].(map[string]any); ok {
+			if pct := asString(prog["progress"]); pct != "" {
+				task.Progress = pct + "%"
+			}
+		}
+		out = append(out, task)
+	}
+	return out
+}
+
+// BackupTasks fetches Hyper Backup tasks.
+func (m *MonitoringService) BackupTasks(ctx context.Context) ([]BackupTask, error) {
+	resp, err := m.dsm.BackupTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := dsmData(resp)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBackupTasks(data), nil
+}
+
+// ActiveBackups returns names of backup tasks currently running.
+func (m *MonitoringService) ActiveBackups(ctx context.Context) ([]string, error) {
+	tasks, err := m.BackupTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, t := range tasks {
+		if t.Running() {
+			names = append(names, t.Name)
+		}
+	}
+	return names, nil
+}
+
+// Backups renders Hyper Backup tasks followed by scheduled tasks.
+func (m *MonitoringService) Backups(ctx context.Context) (string, error) {
+	tasks, err := m.BackupTasks(ctx)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{"Hyper Backup:"}
+	if len(tasks) == 0 {
+		lines = append(lines, "  (no tasks)")
+	}
+	for _, t := range tasks {
+		state := "idle"
+		if t.Running() {
+			state = "RUNNING " + t.Progress
+		}
+		result := t.LastResult
+		if t.Failed() {
+			result = "FAILED(" + t.LastResult + ")"
+		}
+		line := fmt.Sprintf("  %s: %s last=%s at %s", t.Name, strings.TrimSpace(state), result, orDash(t.LastRun))
+		if t.NextRun != "" {
+			line += " next=" + t.NextRun
+		}
+		lines = append(lines, line)
+	}
+
+	sched, err := m.scheduledTasks(ctx)
+	switch {
+	case err != nil:
+		lines = append(lines, fmt.Sprintf("Scheduled tasks: error: %v", err))
+	case len(sched) > 0:
+		lines = append(lines, "Scheduled tasks:")
+	}
+	for _, t := range sched {
+		state := "disabled"
+		if asBool(t["enable"]) {
+			state = "enabled"
+		}
+		if asBool(t["running"]) {
+			state = "RUNNING"
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s next=%s", asString(t["name"]), state, orDash(asString(t["next_trigger_time"]))))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (m *MonitoringService) scheduledTasks(ctx context.Context) ([]map[string]any, error) {
+	resp, err := m.dsm.ScheduledTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := dsmData(resp)
+	if err != nil {
+		return nil, err
+	}
+	return asList(data["tasks"]), nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
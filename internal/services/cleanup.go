@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"zckyachmd/lifeline/pkg/docker"
+)
+
+// maxPreviewItems caps how many objects are listed in a cleanup preview.
+const maxPreviewItems = 15
+
+// cleanupScopes maps /cleanup scopes to Docker prune kinds.
+var cleanupScopes = map[string]string{
+	"images":     "images",
+	"containers": "containers",
+	"builder":    "build",
+	"networks":   "networks",
+}
+
+// IsCleanupScope reports whether scope is a known /cleanup scope.
+func IsCleanupScope(scope string) bool {
+	_, ok := cleanupScopes[scope]
+	return ok
+}
+
+// CleanupPreview is a dry-run summary of what a cleanup scope would remove.
+type CleanupPreview struct {
+	Scope       string
+	Reclaimable uint64
+	Items       []string
+}
+
+// BuildCleanupPreview computes reclaimable space and candidates from docker system df.
+func BuildCleanupPreview(scope string, du docker.DiskUsage) (CleanupPreview, error) {
+	p := CleanupPreview{Scope: scope}
+	switch scope {
+	case "images":
+		for _, img := range du.Images {
+			if !danglingImage(img.RepoTags) || img.Containers > 0 {
+				continue
+			}
+			size := uint64(img.Size - img.SharedSize)
+			p.Reclaimable += size
+			p.Items = append(p.Items, fmt.Sprintf("%s %s", shortID(strings.TrimPrefix(img.ID, "sha256:")), humanBytes(size)))
+		}
+	case "containers":
+		for _, c := range du.Containers {
+			if c.State == "running" || c.State == "paused" || c.State == "restarting" {
+				continue
+			}
+			p.Reclaimable += uint64(c.SizeRw)
+			name := shortID(c.ID)
+			if len(c.Names) > 0 {
+				name = strings.TrimPrefix(c.Names[0], "/")
+			}
+			p.Items = append(p.Items, fmt.Sprintf("%s (%s) %s", name, c.State, humanBytes(uint64(c.SizeRw))))
+		}
+	case "builder":
+		count := 0
+		for _, b := range du.BuildCache {
+			if b.InUse || b.Shared {
+				continue
+			}
+			p.Reclaimable += uint64(b.Size)
+			count++
+		}
+		if count > 0 {
+			p.Items = append(p.Items, fmt.Sprintf("%d build cache entries", count))
+		}
+	case "networks":
+		p.Items = append(p.Items, "custom networks not used by any container")
+	default:
+		return p, fmt.Errorf("unknown cleanup scope %q", scope)
+	}
+	sort.Strings(p.Items)
+	return p, nil
+}
+
+func danglingImage(tags []string) bool {
+	for _, t := range tags {
+		if t != "<none>:<none>" {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the preview for the confirmation prompt.
+func (p CleanupPreview) String() string {
+	lines := []string{fmt.Sprintf("Cleanup %s (dry run): %s reclaimable", p.Scope, humanBytes(p.Reclaimable))}
+	if len(p.Items) == 0 {
+		lines = append(lines, "nothing to remove")
+	}
+	for i, it := range p.Items {
+		if i == maxPreviewItems {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(p.Items)-maxPreviewItems))
+			break
+		}
+		lines = append(lines, "- "+it)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// CleanupPreview runs docker system df and describes what a scope would remove.
+func (s *SystemService) CleanupPreview(ctx context.Context, scope string) (CleanupPreview, error) {
+	if !IsCleanupScope(scope) {
+		return CleanupPreview{}, fmt.Errorf("unknown cleanup scope %q", scope)
+	}
+	du, err := s.docker.api.DiskUsage(ctx)
+	if err != nil {
+		return CleanupPreview{}, err
+	}
+	return BuildCleanupPreview(scope, du)
+}
+
+// Cleanup prunes a single scope and reports the space actually freed.
+func (s *SystemService) Cleanup(ctx context.Context, scope string) (string, error) {
+	kind, ok := cleanupScopes[scope]
+	if !ok {
+		return "", fmt.Errorf("unknown cleanup scope %q", scope)
+	}
+	rep, err := s.docker.api.Prune(ctx, kind)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Cleanup %s done: removed %d, freed %s", scope, len(rep.Deleted), humanBytes(rep.SpaceReclaimed)), nil
+}
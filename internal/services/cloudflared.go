@@ -0,0 +1,329 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cfReconnectLines = 3
+	cfLogScan        = 500
+)
+
+// errNoMetricsAddr is returned when cloudflared.metrics_addr is empty.
+var errNoMetricsAddr = errors.New("cloudflared.metrics_addr is not configured")
+
+// PromSample is one sample of the Prometheus text exposition format.
+type PromSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// ParsePromText parses Prometheus text exposition, skipping comments and
+// lines it cannot read.
+func ParsePromText(r io.Reader) ([]PromSample, error) {
+	var out []PromSample
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if s, ok := parsePromLine(line); ok {
+			out = append(out, s)
+		}
+	}
+	return out, sc.Err()
+}
+
+// parsePromLine reads `name{k="v",...} value [timestamp]`.
+func parsePromLine(line string) (PromSample, bool) {
+	var s PromSample
+	i := strings.IndexAny(line, "{ ")
+	if i <= 0 {
+		return s, false
+	}
+	s.Name = line[:i]
+	rest := line[i:]
+	if strings.HasPrefix(rest, "{") {
+		labels, tail, ok := parsePromLabels(rest[1:])
+		if !ok {
+			return s, false
+		}
+		s.Labels, rest = labels, tail
+	}
+	f := strings.Fields(rest)
+	if len(f) == 0 {
+		return s, false
+	}
+	v, err := strconv.ParseFloat(f[0], 64)
+	if err != nil {
+		return s, false
+	}
+	s.Value = v
+	return s, true
+}
+
+// parsePromLabels reads label pairs up to the closing brace and returns what follows it.
+func parsePromLabels(in string) (map[string]string, string, bool) {
+	labels := map[string]string{}
+	for {
+		in = strings.TrimLeft(in, ", ")
+		if strings.HasPrefix(in, "}") {
+			return labels, in[1:], true
+		}
+		key, rest, ok := strings.Cut(in, "=")
+		if !ok || !strings.HasPrefix(rest, `"`) {
+			return nil, "", false
+		}
+		var val strings.Builder
+		i := 1
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+				if rest[i] == 'n' {
+					val.WriteByte('\n')
+					continue
+				}
+			}
+			val.WriteByte(rest[i])
+		}
+		if i >= len(rest) {
+			return nil, "", false
+		}
+		labels[strings.TrimSpace(key)] = val.String()
+		in = rest[i+1:]
+	}
+}
+
+// CloudflaredMetrics is the subset of cloudflared's /metrics we report.
+type CloudflaredMetrics struct {
+	HAConnections    int
+	EdgeLocations    []string           // "connection_id:location", sorted
+	Requests         float64            // cumulative proxied requests
+	RequestErrors    float64            // cumulative proxy errors
+	RegisterFailures map[string]float64 // tunnel registration failures by error
+}
+
+// CloudflaredMetricsFrom picks the tunnel metrics out of a scrape.
+func CloudflaredMetricsFrom(samples []PromSample) CloudflaredMetrics {
+	cm := CloudflaredMetrics{RegisterFailures: map[string]float64{}}
+	for _, s := range samples {
+		switch s.Name {
+		case "cloudflared_tunnel_ha_connections":
+			cm.HAConnections = int(s.Value)
+		case "cloudflared_tunnel_server_locations":
+			if s.Value > 0 {
+				cm.EdgeLocations = append(cm.EdgeLocations, s.Labels["connection_id"]+":"+s.Labels["edge_location"])
+			}
+		case "cloudflared_tunnel_total_requests":
+			cm.Requests += s.Value
+		case "cloudflared_tunnel_request_errors":
+			cm.RequestErrors += s.Value
+		case "cloudflared_tunnel_tunnel_register_fail":
+			if s.Value > 0 {
+				cm.RegisterFailures[s.Labels["error"]] += s.Value
+			}
+		}
+	}
+	sort.Strings(cm.EdgeLocations)
+	return cm
+}
+
+// CloudflaredReady is the body of cloudflared's /ready endpoint.
+type CloudflaredReady struct {
+	Status           int `json:"status"`
+	ReadyConnections int `json:"readyConnections"`
+}
+
+// OK reports whether the tunnel has at least one edge connection.
+func (r CloudflaredReady) OK() bool {
+	return r.Status == http.StatusOK && r.ReadyConnections > 0
+}
+
+// fetchCloudflaredReady queries /ready on the metrics server.
+func fetchCloudflaredReady(ctx context.Context, client *http.Client, addr string) (CloudflaredReady, error) {
+	var ready CloudflaredReady
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/ready", nil)
+	if err != nil {
+		return ready, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ready, fmt.Errorf("ready probe: %w", err)
+	}
+	defer resp.Body.Close()
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&ready)
+	ready.Status = resp.StatusCode
+	return ready, nil
+}
+
+// fetchCloudflaredMetrics scrapes /metrics on the metrics server.
+func fetchCloudflaredMetrics(ctx context.Context, client *http.Client, addr string) (CloudflaredMetrics, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+"/metrics", nil)
+	if err != nil {
+		return CloudflaredMetrics{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return CloudflaredMetrics{}, fmt.Errorf("metrics scrape: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return CloudflaredMetrics{}, fmt.Errorf("metrics scrape: HTTP %d", resp.StatusCode)
+	}
+	samples, err := ParsePromText(io.LimitReader(resp.Body, 4*1024*1024))
+	if err != nil {
+		return CloudflaredMetrics{}, fmt.Errorf("metrics scrape: %w", err)
+	}
+	return CloudflaredMetricsFrom(samples), nil
+}
+
+// reconnectLineRe matches cloudflared log lines explaining a dropped or retried edge connection.
+var reconnectLineRe = regexp.MustCompile(`(?i)retrying connection|connection terminated|unregistered tunnel connection|serve tunnel error|failed to (serve|dial|connect)`)
+
+// ReconnectReasons returns the last n log lines that explain an edge reconnect.
+func ReconnectReasons(logs string, n int) []string {
+	var out []string
+	for _, line := range strings.Split(logs, "\n") {
+		if reconnectLineRe.MatchString(line) {
+			out = append(out, clip(strings.TrimSpace(line), 200))
+		}
+	}
+	if len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return out
+}
+
+// cloudflaredHealth reports container state plus edge connectivity.
+// A running container with zero ready connections is unhealthy.
+func (m *MonitoringService) cloudflaredHealth(ctx context.Context) (healthy bool, detail string, err error) {
+	st, err := m.docker.Status(ctx, "cloudflared")
+	if err != nil {
+		return false, "", err
+	}
+	if !strings.HasPrefix(st, "running") {
+		return false, "container " + st, nil
+	}
+	if m.cloudflared.MetricsAddr == "" {
+		return true, st, nil
+	}
+	ready, err := fetchCloudflaredReady(ctx, m.http, m.cloudflared.MetricsAddr)
+	switch {
+	case err != nil:
+		return false, st + ", " + err.Error(), nil
+	case !ready.OK():
+		return false, fmt.Sprintf("%s but 0 edge connections (ready %d)", st, ready.Status), nil
+	}
+	return true, fmt.Sprintf("%s, %d edge connections", st, ready.ReadyConnections), nil
+}
+
+// CloudflaredDownCheck fires while the tunnel container is down or has no edge connections.
+func (m *MonitoringService) CloudflaredDownCheck(ctx context.Context) (bool, string, error) {
+	healthy, detail, err := m.cloudflaredHealth(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	if healthy {
+		return false, "cloudflared " + detail, nil
+	}
+	return true, "cloudflared " + detail, nil
+}
+
+// cfScrape remembers the previous /cf metrics scrape, so request rates come
+// from the interval between two commands instead of a sleep in the handler.
+type cfScrape struct {
+	mu      sync.Mutex
+	at      time.Time
+	metrics CloudflaredMetrics
+}
+
+// swap stores cur and returns the previous scrape; a zero time means none.
+func (s *cfScrape) swap(cur CloudflaredMetrics, now time.Time) (CloudflaredMetrics, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, at := s.metrics, s.at
+	s.metrics, s.at = cur, now
+	return prev, at
+}
+
+// Cloudflared reports tunnel health from the metrics server: ready state, HA
+// connections, edge locations, request/error rates since the previous call
+// and recent reconnect reasons.
+func (m *MonitoringService) Cloudflared(ctx context.Context) (string, error) {
+	addr := m.cloudflared.MetricsAddr
+	if addr == "" {
+		return "", errNoMetricsAddr
+	}
+	healthy, detail, err := m.cloudflaredHealth(ctx)
+	if err != nil {
+		detail = "container: " + err.Error()
+	}
+	lines := []string{"cloudflared: " + detail}
+
+	cur, err := fetchCloudflaredMetrics(ctx, m.http, addr)
+	if err != nil {
+		lines = append(lines, err.Error())
+	} else {
+		lines = append(lines, fmt.Sprintf("HA connections: %d", cur.HAConnections))
+		if len(cur.EdgeLocations) > 0 {
+			lines = append(lines, "edge locations: "+strings.Join(cur.EdgeLocations, ", "))
+		}
+		totals := fmt.Sprintf("total %.0f, errors %.0f", cur.Requests, cur.RequestErrors)
+		now := time.Now()
+		if prev, at := m.cfLast.swap(cur, now); at.IsZero() {
+			lines = append(lines, "requests: "+totals+" (rates from the next /cf)")
+		} else {
+			secs := now.Sub(at).Seconds()
+			lines = append(lines, fmt.Sprintf("requests: %.2f/s, errors: %.2f/s over %s (%s)",
+				counterRate(prev.Requests, cur.Requests, secs), counterRate(prev.RequestErrors, cur.RequestErrors, secs),
+				now.Sub(at).Round(time.Second), totals))
+		}
+		if len(cur.RegisterFailures) > 0 {
+			keys := make([]string, 0, len(cur.RegisterFailures))
+			for k := range cur.RegisterFailures {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			lines = append(lines, "registration failures:")
+			for _, k := range keys {
+				lines = append(lines, fmt.Sprintf("  %.0f× %s", cur.RegisterFailures[k], orDash(k)))
+			}
+		}
+	}
+
+	if logs, err := m.docker.Logs(ctx, "cloudflared", cfLogScan); err == nil {
+		if reasons := ReconnectReasons(logs, cfReconnectLines); len(reasons) > 0 {
+			lines = append(lines, "last reconnects:")
+			for _, r := range reasons {
+				lines = append(lines, "  "+r)
+			}
+		}
+	}
+	if !healthy {
+		lines = append(lines, "UNHEALTHY: tunnel is not serving traffic")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// counterRate is the per-second increase of a counter; a reset counts as zero.
+func counterRate(before, after, secs float64) float64 {
+	if after < before || secs <= 0 {
+		return 0
+	}
+	return (after - before) / secs
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"zckyachmd/lifeline/internal/config"
+)
+
+const (
+	// composeTimeout bounds each docker compose step; pulling is never done.
+	composeTimeout = 5 * time.Minute
+	// composeStepArg is the index of the subcommand after "compose -p <project> -f <file>".
+	composeStepArg = 5
+)
+
+func (s *SystemService) stack(name string) (config.ComposeStack, bool) {
+	for _, st := range s.stacks {
+		if st.Name == name {
+			return st, true
+		}
+	}
+	return config.ComposeStack{}, false
+}
+
+// ComposeSteps returns the docker arguments of each step of a stack restart.
+func ComposeSteps(st config.ComposeStack) [][]string {
+	base := []string{"compose", "-p", st.Project, "-f", st.File}
+	if st.Action == "recreate" {
+		return [][]string{
+			append(append([]string{}, base...), "down"),
+			append(append([]string{}, base...), "up", "-d", "--no-build", "--pull", "never"),
+		}
+	}
+	return [][]string{append(append([]string{}, base...), "restart")}
+}
+
+// restartStack runs the declared restart sequence and reports per-container
+// state. ctx cancellation kills the running step.
+func (s *SystemService) restartStack(ctx context.Context, st config.ComposeStack) (string, error) {
+	lines := []string{fmt.Sprintf("stack %s (%s):", st.Name, st.Action)}
+	for _, args := range ComposeSteps(st) {
+		out, err := runCmdTimeout(ctx, s.exec, composeTimeout, "docker", args...)
+		step := args[composeStepArg]
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s failed: %v", step, err))
+			if tail := lastLines(out, 5); tail != "" {
+				lines = append(lines, tail)
+			}
+			return strings.Join(lines, "\n"), fmt.Errorf("compose %s failed for %s", step, st.Name)
+		}
+		lines = append(lines, step+" ok")
+	}
+
+	containers, err := s.docker.api.List(ctx, true, "com.docker.compose.project="+st.Project)
+	if err != nil {
+		lines = append(lines, fmt.Sprintf("container state unavailable: %v", err))
+		return strings.Join(lines, "\n"), nil
+	}
+	if len(containers) == 0 {
+		lines = append(lines, "no containers found for project")
+	}
+	for _, c := range containers {
+		lines = append(lines, fmt.Sprintf("  %s: %s (%s)", c.Name(), c.State, c.Status))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// lastLines returns the last n non-empty lines of output.
+func lastLines(out string, n int) string {
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"zckyachmd/lifeline/pkg/docker"
+)
+
+// CrashLoopTracker remembers restart counts between observations and flags
+// containers whose count keeps rising within a window. It only sees what it
+// is fed: /containers queries and, when enabled, the container_crash_loop
+// alert, which samples every alert interval.
+type CrashLoopTracker struct {
+	window    time.Duration
+	threshold int
+	mu        sync.Mutex
+	samples   map[string][]restartSample
+}
+
+type restartSample struct {
+	at    time.Time
+	count int
+}
+
+// NewCrashLoopTracker flags a container after threshold restarts within window.
+func NewCrashLoopTracker(window time.Duration, threshold int) *CrashLoopTracker {
+	return &CrashLoopTracker{window: window, threshold: threshold, samples: make(map[string][]restartSample)}
+}
+
+// Observe records a restart count and reports whether the container is crash-looping.
+func (t *CrashLoopTracker) Observe(name string, count int, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	kept := t.samples[name][:0]
+	for _, s := range t.samples[name] {
+		// a lower count means the container was recreated; start over
+		if now.Sub(s.at) <= t.window && s.count <= count {
+			kept = append(kept, s)
+		}
+	}
+	kept = append(kept, restartSample{at: now, count: count})
+	t.samples[name] = kept
+	return count-kept[0].count >= t.threshold
+}
+
+// ContainerInfo is the per-container view used by /containers.
+type ContainerInfo struct {
+	Name         string
+	Image        string
+	State        string
+	Health       string
+	Uptime       time.Duration
+	RestartCount int
+	ExitCode     int
+	CrashLoop    bool
+}
+
+func (m *MonitoringService) containerInfo(ctx context.Context, name string, now time.Time) (ContainerInfo, error) {
+	c, err := m.docker.api.Inspect(ctx, name)
+	if err != nil {
+		return ContainerInfo{Name: name}, err
+	}
+	info := ContainerInfo{
+		Name:         strings.TrimPrefix(c.Name, "/"),
+		Image:        c.Config.Image,
+		State:        c.State.Status,
+		Health:       c.HealthStatus(),
+		RestartCount: c.RestartCount,
+		ExitCode:     c.State.ExitCode,
+	}
+	if c.State.Running {
+		if started, err := time.Parse(time.RFC3339Nano, c.State.StartedAt); err == nil {
+			info.Uptime = now.Sub(started).Round(time.Second)
+		}
+	}
+	info.CrashLoop = m.crashLoops.Observe(info.Name, info.RestartCount, now) || c.State.Restarting
+	return info, nil
+}
+
+// Containers inspects every container, including stopped ones.
+func (m *MonitoringService) Containers(ctx context.Context) ([]ContainerInfo, error) {
+	list, err := m.docker.api.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	out := make([]ContainerInfo, 0, len(list))
+	for _, s := range list {
+		info, err := m.containerInfo(ctx, s.Name(), now)
+		if err != nil {
+			info.State = s.State
+			info.Image = s.Image
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// ContainersReport renders the /containers overview with problems flagged.
+func (m *MonitoringService) ContainersReport(ctx context.Context) (string, error) {
+	list, err := m.Containers(ctx)
+	if err != nil {
+		return "", err
+	}
+	running, unhealthy, looping := 0, 0, 0
+	lines := make([]string, 0, len(list)+1)
+	for _, c := range list {
+		if c.State == "running" {
+			running++
+		}
+		if c.Health == "unhealthy" {
+			unhealthy++
+		}
+		if c.CrashLoop {
+			looping++
+		}
+		lines = append(lines, c.line())
+	}
+	header := fmt.Sprintf("%d containers, %d running, %d unhealthy, %d crash-looping", len(list), running, unhealthy, looping)
+	return header + "\n" + strings.Join(lines, "\n"), nil
+}
+
+// CrashLoopCheck samples every container's restart count and fires while
+// any of them is crash-looping.
+func (m *MonitoringService) CrashLoopCheck(ctx context.Context) (bool, string, error) {
+	list, err := m.Containers(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	var looping []string
+	for _, c := range list {
+		if c.CrashLoop {
+			looping = append(looping, fmt.Sprintf("%s (restarts=%d)", c.Name, c.RestartCount))
+		}
+	}
+	if len(looping) == 0 {
+		return false, "no containers crash-looping", nil
+	}
+	return true, "crash-looping: " + strings.Join(looping, ", "), nil
+}
+
+// ContainerDetail renders a single container.
+func (m *MonitoringService) ContainerDetail(ctx context.Context, name string) (string, error) {
+	if !docker.ValidName(name) {
+		return "", fmt.Errorf("invalid container name")
+	}
+	c, err := m.containerInfo(ctx, name, time.Now())
+	if err != nil {
+		return "", err
+	}
+	lines := []string{
+		fmt.Sprintf("name: %s", c.Name),
+		fmt.Sprintf("image: %s", c.Image),
+		fmt.Sprintf("state: %s", c.State),
+		fmt.Sprintf("health: %s", orDash(c.Health)),
+		fmt.Sprintf("uptime: %s", c.Uptime),
+		fmt.Sprintf("restarts: %d", c.RestartCount),
+		fmt.Sprintf("exit code: %d", c.ExitCode),
+	}
+	if c.CrashLoop {
+		lines = append(lines, "!! CRASH-LOOPING")
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (c ContainerInfo) line() string {
+	parts := []string{c.Name, c.State}
+	if c.Health != "" {
+		parts = append(parts, c.Health)
+	}
+	if c.State == "running" {
+		parts = append(parts, "up="+c.Uptime.String())
+	} else {
+		parts = append(parts, fmt.Sprintf("exit=%d", c.ExitCode))
+	}
+	parts = append(parts, fmt.Sprintf("restarts=%d", c.RestartCount))
+	if c.CrashLoop {
+		parts = append(parts, "CRASH-LOOP")
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DDNSRecord is a single DSM DDNS entry.
+type DDNSRecord struct {
+	ID       string
+	Provider string
+	Hostname string
+	IP       string
+	Status   string
+}
+
+// ParseDDNSRecords extracts records from a SYNO.Core.DDNS.Record list payload.
+func ParseDDNSRecords(data map[string]any) []DDNSRecord {
+	items := asList(data["records"])
+	out := make([]DDNSRecord, 0, len(items))
+	for _, r := range items {
+		out = append(out, DDNSRecord{
+			ID:       asString(r["id"]),
+			Provider: asString(r["provider"]),
+			Hostname: asString(r["hostname"]),
+			IP:       asString(r["ip"]),
+			Status:   strings.TrimPrefix(asString(r["status"]), "service_ddns_"),
+		})
+	}
+	return out
+}
+
+// DDNSMismatches returns records whose registered IP differs from publicIP.
+func DDNSMismatches(records []DDNSRecord, publicIP string) []DDNSRecord {
+	publicIP = strings.TrimSpace(publicIP)
+	if publicIP == "" {
+		return nil
+	}
+	var out []DDNSRecord
+	for _, r := range records {
+		if r.IP != publicIP {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// DDNSRecords fetches DDNS records from DSM.
+func (m *MonitoringService) DDNSRecords(ctx context.Context) ([]DDNSRecord, error) {
+	resp, err := m.dsm.DDNSRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := dsmData(resp)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDDNSRecords(data), nil
+}
+
+// DDNS renders DDNS records compared with the current public IP.
+func (m *MonitoringService) DDNS(ctx context.Context) (string, error) {
+	records, err := m.DDNSRecords(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(records) == 0 {
+		return "No DDNS records configured", nil
+	}
+	ip, ipErr := m.PublicIP(ctx)
+	ip = strings.TrimSpace(ip)
+
+	lines := make([]string, 0, len(records)+1)
+	if ipErr != nil {
+		lines = append(lines, fmt.Sprintf("public ip: unknown (%v)", ipErr))
+	} else {
+		lines = append(lines, fmt.Sprintf("public ip: %s", ip))
+	}
+	for _, r := range records {
+		flag := ""
+		if ipErr == nil && r.IP != ip {
+			flag = " MISMATCH"
+		}
+		lines = append(lines, fmt.Sprintf("%s [%s] ip=%s status=%s%s", r.Hostname, r.Provider, r.IP, r.Status, flag))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// ddnsHealth summarises DDNS state for /health; empty when DSM has no records.
+func (m *MonitoringService) ddnsHealth(ctx context.Context) string {
+	records, err := m.DDNSRecords(ctx)
+	if err != nil {
+		return fmt.Sprintf("DDNS: error: %v", err)
+	}
+	if len(records) == 0 {
+		return ""
+	}
+	ip, err := m.PublicIP(ctx)
+	if err != nil {
+		return "DDNS: public ip unknown"
+	}
+	bad := DDNSMismatches(records, ip)
+	if len(bad) == 0 {
+		return "DDNS: OK"
+	}
+	hosts := make([]string, 0, len(bad))
+	for _, r := range bad {
+		hosts = append(hosts, fmt.Sprintf("%s=%s", r.Hostname, r.IP))
+	}
+	return fmt.Sprintf("DDNS: MISMATCH public=%s %s", strings.TrimSpace(ip), strings.Join(hosts, " "))
+}
+
+// UpdateDDNS forces DSM to refresh all DDNS records.
+func (s *SystemService) UpdateDDNS(ctx context.Context) (string, error) {
+	resp, err := s.dsm.DDNSUpdate(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, err := dsmData(resp); err != nil {
+		return "", err
+	}
+	return "DDNS update requested", nil
+}
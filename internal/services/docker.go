@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"zckyachmd/lifeline/pkg/docker"
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+// DockerRuntime reaches containers through the Engine API socket and,
+// when enabled, falls back to the docker CLI if the socket cannot be dialled.
+type DockerRuntime struct {
+	api         *docker.Client
+	exec        *executor.Runner
+	cliFallback bool
+}
+
+// NewDockerRuntime wraps an Engine API client.
+func NewDockerRuntime(api *docker.Client, runner *executor.Runner, cliFallback bool) *DockerRuntime {
+	return &DockerRuntime{api: api, exec: runner, cliFallback: cliFallback}
+}
+
+// useCLI decides whether an API error should be retried through the CLI.
+// Only connection failures qualify: a daemon that times out or answers with
+// an error would fail (or hang) the same way through the CLI.
+func (d *DockerRuntime) useCLI(err error) bool {
+	return d.cliFallback && docker.Unreachable(err)
+}
+
+// Status returns container state, including healthcheck status when defined.
+func (d *DockerRuntime) Status(ctx context.Context, name string) (string, error) {
+	info, err := d.api.Inspect(ctx, name)
+	if d.useCLI(err) {
+		out, cliErr := runCmd(ctx, d.exec, "docker", "inspect", "-f", "{{.State.Status}}", name)
+		return strings.TrimSpace(out), cliErr
+	}
+	if err != nil {
+		return "", err
+	}
+	status := info.State.Status
+	if h := info.HealthStatus(); h != "" {
+		status += " (" + h + ")"
+	}
+	return status, nil
+}
+
+// Restart restarts a container.
+func (d *DockerRuntime) Restart(ctx context.Context, name string) (string, error) {
+	err := d.api.Restart(ctx, name, 10)
+	if d.useCLI(err) {
+		return runCmd(ctx, d.exec, "docker", "restart", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s restarted", name), nil
+}
+
+// Logs returns the last lines of a container's stdout/stderr.
+func (d *DockerRuntime) Logs(ctx context.Context, name string, lines int) (string, error) {
+	out, err := d.api.Logs(ctx, name, docker.LogOptions{Tail: lines})
+	if d.useCLI(err) {
+		return runCmd(ctx, d.exec, "docker", "logs", "--tail", fmt.Sprintf("%d", lines), name)
+	}
+	return out, err
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// dsmData unwraps the standard DSM envelope ({success, data, error}).
+func dsmData(resp map[string]any) (map[string]any, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("empty dsm response")
+	}
+	if ok, present := resp["success"].(bool); present && !ok {
+		code := "unknown"
+		if e, ok := resp["error"].(map[string]any); ok {
+			code = asString(e["code"])
+		}
+		return nil, fmt.Errorf("dsm error code %s", code)
+	}
+	data, _ := resp["data"].(map[string]any)
+	if data == nil {
+		data = map[string]any{}
+	}
+	return data, nil
+}
+
+// asString renders loosely typed JSON values (string/number/bool) as text.
+func asString(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+// asFloat converts JSON numbers or numeric strings to float64.
+func asFloat(v any) float64 {
+	switch t := v.(type) {
+	case float64:
+		return t
+	case string:
+		f, _ := strconv.ParseFloat(t, 64)
+		return f
+	case bool:
+		if t {
+			return 1
+		}
+	}
+	return 0
+}
+
+// asBool accepts JSON booleans as well as "true"/"yes"/1 style values.
+func asBool(v any) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		switch t {
+		case "true", "yes", "1", "on":
+			return true
+		}
+	}
+	return false
+}
+
+// asList returns a JSON array of objects, skipping non-object items.
+func asList(v any) []map[string]any {
+	raw, _ := v.([]any)
+	out := make([]map[string]any, 0, len(raw))
+	for _, item := range raw {
+		if m, ok := item.(map[string]any); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"zckyachmd/lifeline/pkg/docker"
+)
+
+// cgroupRoot is where the cgroup fallback looks for per-container accounting.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// ContainerStat is one sample of per-container resource usage.
+type ContainerStat struct {
+	Name     string
+	CPU      float64
+	MemUsage uint64
+	MemLimit uint64
+	NetRx    uint64
+	NetTx    uint64
+	BlkRead  uint64
+	BlkWrite uint64
+}
+
+// CalcStats derives CPU %, memory working set and I/O totals from a Docker stats sample.
+func CalcStats(s docker.Stats) ContainerStat {
+	st := ContainerStat{Name: strings.TrimPrefix(s.Name, "/")}
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	sysDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	cpus := float64(s.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && sysDelta > 0 {
+		st.CPU = cpuDelta / sysDelta * cpus * 100
+	}
+
+	st.MemUsage = s.MemoryStats.Usage
+	// match `docker stats`: exclude reclaimable page cache
+	if v, ok := s.MemoryStats.Stats["inactive_file"]; ok && v < st.MemUsage {
+		st.MemUsage -= v
+	} else if v, ok := s.MemoryStats.Stats["total_inactive_file"]; ok && v < st.MemUsage {
+		st.MemUsage -= v
+	}
+	st.MemLimit = s.MemoryStats.Limit
+
+	for _, n := range s.Networks {
+		st.NetRx += n.RxBytes
+		st.NetTx += n.TxBytes
+	}
+	for _, b := range s.BlkioStats.IOServiceBytesRecursive {
+		switch strings.ToLower(b.Op) {
+		case "read":
+			st.BlkRead += b.Value
+		case "write":
+			st.BlkWrite += b.Value
+		}
+	}
+	return st
+}
+
+// ContainerStats samples every running container via the stats API, falling
+// back to cgroup accounting when the daemon cannot be reached. Containers whose
+// stats call failed are returned by name in unavailable.
+func (m *MonitoringService) ContainerStats(ctx context.Context) (stats []ContainerStat, unavailable []string, err error) {
+	list, err := m.docker.api.List(ctx, false)
+	if err != nil {
+		stats, cgErr := cgroupStats(ctx)
+		if cgErr != nil {
+			return nil, nil, fmt.Errorf("docker api: %v; cgroup: %v", err, cgErr)
+		}
+		return stats, nil, nil
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, 8)
+	)
+	stats = make([]ContainerStat, 0, len(list))
+	for _, c := range list {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			raw, err := m.docker.api.Stats(ctx, name)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				unavailable = append(unavailable, name)
+				return
+			}
+			st := CalcStats(raw)
+			st.Name = name
+			stats = append(stats, st)
+		}(c.Name())
+	}
+	wg.Wait()
+	sort.Strings(unavailable)
+	return stats, unavailable, nil
+}
+
+// SortStats orders samples by cpu, mem, net or io (descending) and keeps top n.
+func SortStats(stats []ContainerStat, by string, n int) ([]ContainerStat, error) {
+	var key func(ContainerStat) float64
+	switch by {
+	case "cpu":
+		key = func(s ContainerStat) float64 { return s.CPU }
+	case "mem":
+		key = func(s ContainerStat) float64 { return float64(s.MemUsage) }
+	case "net":
+		key = func(s ContainerStat) float64 { return float64(s.NetRx + s.NetTx) }
+	case "io":
+		key = func(s ContainerStat) float64 { return float64(s.BlkRead + s.BlkWrite) }
+	default:
+		return nil, fmt.Errorf("unknown sort key %q (cpu|mem|net|io)", by)
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return key(stats[i]) > key(stats[j]) })
+	if n > 0 && len(stats) > n {
+		stats = stats[:n]
+	}
+	return stats, nil
+}
+
+// DStats renders the top n containers by the given metric.
+func (m *MonitoringService) DStats(ctx context.Context, by string, n int) (string, error) {
+	if _, err := SortStats(nil, by, n); err != nil {
+		return "", err
+	}
+	stats, unavailable, err := m.ContainerStats(ctx)
+	if err != nil {
+		return "", err
+	}
+	total := len(stats)
+	stats, err = SortStats(stats, by, n)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{fmt.Sprintf("top %d of %d by %s", len(stats), total, by)}
+	for _, s := range stats {
+		mem := humanBytes(s.MemUsage)
+		if s.MemLimit > 0 {
+			mem += "/" + humanBytes(s.MemLimit)
+		}
+		lines = append(lines, fmt.Sprintf("%s cpu=%.1f%% mem=%s net=%s/%s io=%s/%s",
+			s.Name, s.CPU, mem, humanBytes(s.NetRx), humanBytes(s.NetTx), humanBytes(s.BlkRead), humanBytes(s.BlkWrite)))
+	}
+	if len(unavailable) > 0 {
+		lines = append(lines, "stats unavailable: "+strings.Join(unavailable, ", "))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// cgroupSample is raw cgroup accounting for one container.
+type cgroupSample struct {
+	cpuNanos uint64
+	mem      uint64
+	memLimit uint64
+	read     uint64
+	write    uint64
+}
+
+// cgroupStats samples docker container cgroups twice, one second apart.
+// Names are short container ids since the daemon is unavailable.
+func cgroupStats(ctx context.Context) ([]ContainerStat, error) {
+	first, err := readCgroups()
+	if err != nil {
+		return nil, err
+	}
+	if len(first) == 0 {
+		return nil, fmt.Errorf("no docker cgroups found under %s", cgroupRoot)
+	}
+	start := time.Now()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Second):
+	}
+	second, err := readCgroups()
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+
+	out := make([]ContainerStat, 0, len(second))
+	for id, s := range second {
+		st := ContainerStat{Name: id, MemUsage: s.mem, MemLimit: s.memLimit, BlkRead: s.read, BlkWrite: s.write}
+		if p, ok := first[id]; ok && s.cpuNanos > p.cpuNanos {
+			st.CPU = float64(s.cpuNanos-p.cpuNanos) / float64(elapsed.Nanoseconds()) * 100
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// readCgroups reads cgroup v2 scopes, falling back to the v1 hierarchy.
+func readCgroups() (map[string]cgroupSample, error) {
+	out := make(map[string]cgroupSample)
+	scopes, _ := filepath.Glob(filepath.Join(cgroupRoot, "system.slice", "docker-*.scope"))
+	for _, dir := range scopes {
+		id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(dir), "docker-"), ".scope")
+		var s cgroupSample
+		if kv, err := readKV(filepath.Join(dir, "cpu.stat")); err == nil {
+			s.cpuNanos = kv["usage_usec"] * 1000
+		}
+		s.mem = readUint(filepath.Join(dir, "memory.current"))
+		if kv, err := readKV(filepath.Join(dir, "memory.stat")); err == nil && kv["inactive_file"] < s.mem {
+			s.mem -= kv["inactive_file"]
+		}
+		s.memLimit = readUint(filepath.Join(dir, "memory.max"))
+		s.read, s.write = readIOStatV2(filepath.Join(dir, "io.stat"))
+		out[shortID(id)] = s
+	}
+	if len(out) > 0 {
+		return out, nil
+	}
+
+	dirs, _ := filepath.Glob(filepath.Join(cgroupRoot, "memory", "docker", "*"))
+	for _, dir := range dirs {
+		id := filepath.Base(dir)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		s := cgroupSample{
+			cpuNanos: readUint(filepath.Join(cgroupRoot, "cpuacct", "docker", id, "cpuacct.usage")),
+			mem:      readUint(filepath.Join(dir, "memory.usage_in_bytes")),
+			memLimit: readUint(filepath.Join(dir, "memory.limit_in_bytes")),
+		}
+		s.read, s.write = readIOStatV1(filepath.Join(cgroupRoot, "blkio", "docker", id, "blkio.throttle.io_service_bytes"))
+		out[shortID(id)] = s
+	}
+	return out, nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// readUint reads a single-number cgroup file; "max" and errors yield 0.
+func readUint(path string) uint64 {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	v, _ := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	return v
+}
+
+// readKV reads "key value" lines (cpu.stat, memory.stat).
+func readKV(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	out := make(map[string]uint64)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 {
+			out[fields[0]], _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	return out, sc.Err()
+}
+
+// readIOStatV2 sums rbytes/wbytes across devices in io.stat.
+func readIOStatV2(path string) (uint64, uint64) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0
+	}
+	var r, w uint64
+	for _, f := range strings.Fields(string(b)) {
+		if v, ok := strings.CutPrefix(f, "rbytes="); ok {
+			n, _ := strconv.ParseUint(v, 10, 64)
+			r += n
+		} else if v, ok := strings.CutPrefix(f, "wbytes="); ok {
+			n, _ := strconv.ParseUint(v, 10, 64)
+			w += n
+		}
+	}
+	return r, w
+}
+
+// readIOStatV1 sums Read/Write lines of blkio.throttle.io_service_bytes.
+func readIOStatV1(path string) (uint64, uint64) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0
+	}
+	var r, w uint64
+	for _, line := range strings.Split(string(b), "\n") {
+		f := strings.Fields(line)
+		if len(f) != 3 {
+			continue
+		}
+		n, _ := strconv.ParseUint(f[2], 10, 64)
+		switch f[1] {
+		case "Read":
+			r += n
+		case "Write":
+			w += n
+		}
+	}
+	return r, w
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DUEntry is a directory or file with its disk usage.
+type DUEntry struct {
+	Path string
+	Size int64
+}
+
+// DUReport is the result of a bounded disk usage walk.
+type DUReport struct {
+	Root      string
+	Total     int64
+	Entries   int
+	Truncated bool
+	Dirs      []DUEntry
+	Files     []DUEntry
+}
+
+// DUBudget limits a walk by visited entries and wall time.
+type DUBudget struct {
+	MaxEntries int
+	Timeout    time.Duration
+	Top        int
+}
+
+var errBudget = errors.New("budget exhausted")
+
+// WalkDiskUsage sums allocated bytes under root, attributing sizes to
+// subdirectories up to depth levels deep. It never follows symlinks or
+// crosses into other filesystems.
+func WalkDiskUsage(ctx context.Context, root string, depth int, b DUBudget) (DUReport, error) {
+	rep := DUReport{Root: root}
+	var rootStat syscall.Stat_t
+	if err := syscall.Lstat(root, &rootStat); err != nil {
+		return rep, err
+	}
+	deadline := time.Now().Add(b.Timeout)
+	dirs := map[string]int64{}
+	var files []DUEntry
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rep.Entries++
+		if rep.Entries > b.MaxEntries || time.Now().After(deadline) || ctx.Err() != nil {
+			return errBudget
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		st, _ := info.Sys().(*syscall.Stat_t)
+		if d.IsDir() {
+			if st != nil && st.Dev != rootStat.Dev {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		size := info.Size()
+		if st != nil {
+			size = st.Blocks * 512
+		}
+		rep.Total += size
+		files = append(files, DUEntry{Path: p, Size: size})
+
+		rel, _ := filepath.Rel(root, filepath.Dir(p))
+		if rel == "." {
+			return nil
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		for i := 1; i <= depth && i <= len(parts); i++ {
+			dirs[filepath.Join(parts[:i]...)] += size
+		}
+		return nil
+	})
+	if errors.Is(err, errBudget) {
+		rep.Truncated = true
+	} else if err != nil {
+		return rep, err
+	}
+
+	for p, s := range dirs {
+		rep.Dirs = append(rep.Dirs, DUEntry{Path: p, Size: s})
+	}
+	sort.Slice(rep.Dirs, func(i, j int) bool { return rep.Dirs[i].Size > rep.Dirs[j].Size })
+	sort.Slice(files, func(i, j int) bool { return files[i].Size > files[j].Size })
+	if len(rep.Dirs) > b.Top {
+		rep.Dirs = rep.Dirs[:b.Top]
+	}
+	if len(files) > b.Top {
+		files = files[:b.Top]
+	}
+	for i := range files {
+		if rel, err := filepath.Rel(root, files[i].Path); err == nil {
+			files[i].Path = rel
+		}
+	}
+	rep.Files = files
+	return rep, nil
+}
+
+// DiskUsage walks an allowlisted alias and reports the largest entries plus mount usage.
+func (m *MonitoringService) DiskUsage(ctx context.Context, alias string, depth int) (string, error) {
+	root, ok := m.du.Paths[alias]
+	if !ok {
+		return "", fmt.Errorf("unknown path alias %q", alias)
+	}
+	if depth < 1 || depth > 3 {
+		return "", fmt.Errorf("depth must be 1-3")
+	}
+	rep, err := WalkDiskUsage(ctx, root, depth, DUBudget{
+		MaxEntries: m.du.MaxEntries,
+		Timeout:    time.Duration(m.du.TimeoutSeconds) * time.Second,
+		Top:        m.du.Top,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{fmt.Sprintf("%s (%s): %s in %d entries", alias, root, humanBytes(uint64(rep.Total)), rep.Entries)}
+	if rep.Truncated {
+		lines[0] += " [PARTIAL: budget exhausted]"
+	}
+	var sfs syscall.Statfs_t
+	if err := syscall.Statfs(root, &sfs); err == nil {
+		total := sfs.Blocks * uint64(sfs.Bsize)
+		free := sfs.Bavail * uint64(sfs.Bsize)
+		line := fmt.Sprintf("mount: %s free of %s", humanBytes(free), humanBytes(total))
+		if sfs.Files > 0 {
+			used := sfs.Files - sfs.Ffree
+			line += fmt.Sprintf(", inodes %d/%d (%.0f%%)", used, sfs.Files, float64(used)/float64(sfs.Files)*100)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "dirs:")
+	for _, d := range rep.Dirs {
+		lines = append(lines, fmt.Sprintf("  %s %s", humanBytes(uint64(d.Size)), d.Path))
+	}
+	lines = append(lines, "files:")
+	for _, f := range rep.Files {
+		lines = append(lines, fmt.Sprintf("  %s %s", humanBytes(uint64(f.Size)), f.Path))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// DUAliases lists configured /du aliases.
+func (m *MonitoringService) DUAliases() []string {
+	out := make([]string, 0, len(m.du.Paths))
+	for a := range m.du.Paths {
+		out = append(out, a)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFollowLine caps a partial line buffered while waiting for its newline.
+const maxFollowLine = 16 * 1024
+
+// lineWriter splits written bytes into lines and hands each to fn.
+type lineWriter struct {
+	buf []byte
+	fn  func(string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(strings.TrimRight(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+	if len(w.buf) > maxFollowLine {
+		w.fn(string(w.buf))
+		w.buf = w.buf[:0]
+	}
+	return len(p), nil
+}
+
+// FollowWindow keeps the last lines of a live log view and a size-bounded
+// transcript of everything seen.
+type FollowWindow struct {
+	size          int
+	maxTranscript int
+	lines         []string
+	transcript    strings.Builder
+	total         int
+	clipped       bool
+}
+
+// NewFollowWindow keeps size lines on screen and up to maxTranscript bytes of transcript.
+func NewFollowWindow(size, maxTranscript int) *FollowWindow {
+	return &FollowWindow{size: size, maxTranscript: maxTranscript}
+}
+
+// Add appends a line to the window and, while there is room, the transcript.
+func (w *FollowWindow) Add(line string) {
+	w.total++
+	w.lines = append(w.lines, line)
+	if len(w.lines) > w.size {
+		w.lines = w.lines[len(w.lines)-w.size:]
+	}
+	if w.transcript.Len()+len(line) < w.maxTranscript {
+		w.transcript.WriteString(line + "\n")
+	} else {
+		w.clipped = true
+	}
+}
+
+// Total is the number of lines seen.
+func (w *FollowWindow) Total() int { return w.total }
+
+// Clipped reports whether the transcript dropped lines.
+func (w *FollowWindow) Clipped() bool { return w.clipped }
+
+// Transcript returns every line kept so far.
+func (w *FollowWindow) Transcript() string { return w.transcript.String() }
+
+// Render puts header above the window, dropping the oldest lines until the
+// text fits in maxRunes.
+func (w *FollowWindow) Render(header string, maxRunes int) string {
+	for i := 0; i <= len(w.lines); i++ {
+		text := header
+		if i < len(w.lines) {
+			text += "\n\n" + strings.Join(w.lines[i:], "\n")
+		}
+		if utf8.RuneCountInString(text) <= maxRunes {
+			return text
+		}
+	}
+	return header
+}
+
+// FollowLogs streams new log lines of an allowlisted service to fn until ctx
+// is done. fn is called from a single goroutine.
+func (s *SystemService) FollowLogs(ctx context.Context, service string, fn func(line string)) error {
+	w := &lineWriter{fn: fn}
+	var err error
+	switch strings.ToLower(service) {
+	case "cloudflared":
+		err = s.docker.api.FollowLogs(ctx, "cloudflared", w)
+		if s.docker.useCLI(err) {
+			err = s.exec.Stream(ctx, w, "docker", "logs", "--follow", "--tail", "0", "cloudflared")
+		}
+	case "tailscale", "tailscaled":
+		err = s.exec.Stream(ctx, w, "journalctl", "-u", "tailscaled.service", "--no-pager", "-o", "short-iso", "-n", "0", "-f")
+	case "docker":
+		err = s.exec.Stream(ctx, w, "journalctl", "-u", "docker.service", "--no-pager", "-o", "short-iso", "-n", "0", "-f")
+	default:
+		return fmt.Errorf("service not allowed")
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
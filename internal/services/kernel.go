@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var kmsgPath = "/dev/kmsg"
+
+const (
+	maxKmsgBytes     = 4 * 1024 * 1024
+	kernelExamples   = 3
+	kernelExampleLen = 160
+)
+
+// KernelRecord is one kernel log message with its time since boot.
+type KernelRecord struct {
+	SinceBoot time.Duration
+	Message   string
+}
+
+// kernelCategories classify kernel messages; the first match wins, so
+// read-only remounts are not counted as plain filesystem errors.
+var kernelCategories = []struct {
+	Name string
+	Re   *regexp.Regexp
+}{
+	{"readonly", regexp.MustCompile(`(?i)remounting filesystem read-only|forced? (to )?read-only|switching to read-only|read-only file ?system`)},
+	{"oom", regexp.MustCompile(`(?i)out of memory|oom-kill|oom_reaper|invoked oom-killer`)},
+	{"io", regexp.MustCompile(`(?i)i/o error|blk_update_request|medium error|ata\d+(\.\d+)?: (failed command|exception|error)|sense key|device offline`)},
+	{"ext4", regexp.MustCompile(`EXT4-fs (error|warning|\(.*\): (error|warning))`)},
+	{"btrfs", regexp.MustCompile(`(?i)BTRFS (error|critical|warning)|btrfs.*(csum failed|corrupt)`)},
+}
+
+// KernelCategories lists category names in report order.
+func KernelCategories() []string {
+	out := make([]string, 0, len(kernelCategories))
+	for _, c := range kernelCategories {
+		out = append(out, c.Name)
+	}
+	return out
+}
+
+// ClassifyKernelMessage returns the category of a kernel message, or "".
+func ClassifyKernelMessage(msg string) string {
+	for _, c := range kernelCategories {
+		if c.Re.MatchString(msg) {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// ParseKmsg parses /dev/kmsg records ("prio,seq,usec,flags;message").
+// Continuation lines carrying device properties start with a space and are skipped.
+func ParseKmsg(data string) []KernelRecord {
+	var out []KernelRecord
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" || line[0] == ' ' {
+			continue
+		}
+		head, msg, ok := strings.Cut(line, ";")
+		if !ok {
+			continue
+		}
+		f := strings.Split(head, ",")
+		if len(f) < 3 {
+			continue
+		}
+		usec, err := strconv.ParseInt(f[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, KernelRecord{SinceBoot: time.Duration(usec) * time.Microsecond, Message: msg})
+	}
+	return out
+}
+
+// dmesgLineRe matches "[  123.456789] message".
+var dmesgLineRe = regexp.MustCompile(`^\[\s*(\d+)\.(\d+)\]\s?(.*)$`)
+
+// ParseDmesg parses default dmesg output; lines without a timestamp keep
+// the previous record's time.
+func ParseDmesg(out string) []KernelRecord {
+	var recs []KernelRecord
+	var last time.Duration
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if m := dmesgLineRe.FindStringSubmatch(line); m != nil {
+			secs, _ := strconv.ParseInt(m[1], 10, 64)
+			frac, _ := strconv.ParseFloat("0."+m[2], 64)
+			last = time.Duration(secs)*time.Second + time.Duration(frac*float64(time.Second))
+			line = m[3]
+		}
+		recs = append(recs, KernelRecord{SinceBoot: last, Message: line})
+	}
+	return recs
+}
+
+// KernelDigest counts classified kernel events and keeps the latest examples.
+type KernelDigest struct {
+	Source   string
+	Scanned  int
+	Counts   map[string]int
+	Latest   map[string][]KernelRecord // newest last
+	ReadOnly []string                  // data volumes currently mounted read-only
+}
+
+// BuildKernelDigest classifies records and flags read-only data volumes.
+func BuildKernelDigest(source string, recs []KernelRecord, mounts []Mount) KernelDigest {
+	d := KernelDigest{Source: source, Scanned: len(recs), Counts: map[string]int{}, Latest: map[string][]KernelRecord{}}
+	for _, r := range recs {
+		cat := ClassifyKernelMessage(r.Message)
+		if cat == "" {
+			continue
+		}
+		d.Counts[cat]++
+		ex := append(d.Latest[cat], r)
+		if len(ex) > kernelExamples {
+			ex = ex[1:]
+		}
+		d.Latest[cat] = ex
+	}
+	for _, mt := range mounts {
+		if mt.DataVolume() && mt.ReadOnly() {
+			d.ReadOnly = append(d.ReadOnly, fmt.Sprintf("%s (%s %s)", mt.Point, mt.FSType, mt.Device))
+		}
+	}
+	return d
+}
+
+// Render formats the digest; bootTime turns record offsets into ages
+// (zero bootTime prints offsets since boot instead).
+func (d KernelDigest) Render(now, bootTime time.Time) string {
+	lines := []string{fmt.Sprintf("kernel log: %d messages from %s", d.Scanned, d.Source)}
+	if len(d.ReadOnly) > 0 {
+		lines = append(lines, "READ-ONLY data volumes: "+strings.Join(d.ReadOnly, ", "))
+	}
+	var counts []string
+	for _, cat := range KernelCategories() {
+		counts = append(counts, fmt.Sprintf("%s=%d", cat, d.Counts[cat]))
+	}
+	lines = append(lines, strings.Join(counts, " "))
+	for _, cat := range KernelCategories() {
+		ex := d.Latest[cat]
+		if len(ex) == 0 {
+			continue
+		}
+		lines = append(lines, cat+":")
+		for i := len(ex) - 1; i >= 0; i-- {
+			when := "+" + ex[i].SinceBoot.Round(time.Second).String()
+			if !bootTime.IsZero() {
+				when = now.Sub(bootTime.Add(ex[i].SinceBoot)).Round(time.Minute).String() + " ago"
+			}
+			lines = append(lines, fmt.Sprintf("  [%s] %s", when, clip(strings.TrimSpace(ex[i].Message), kernelExampleLen)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readKmsg drains the kernel ring buffer without blocking. syscall is used
+// directly because os.File would park a non-blocking read on the poller.
+func readKmsg() (string, error) {
+	fd, err := syscall.Open(kmsgPath, syscall.O_RDONLY|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return "", err
+	}
+	defer syscall.Close(fd)
+	var sb strings.Builder
+	buf := make([]byte, 16*1024)
+	for sb.Len() < maxKmsgBytes {
+		n, err := syscall.Read(fd, buf)
+		switch {
+		case errors.Is(err, syscall.EAGAIN):
+			return sb.String(), nil
+		case errors.Is(err, syscall.EPIPE):
+			continue // record overwritten while reading; the next read resyncs
+		case err != nil:
+			return sb.String(), err
+		case n == 0:
+			return sb.String(), nil
+		}
+		sb.Write(buf[:n])
+		if buf[n-1] != '\n' {
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String(), nil
+}
+
+// kernelRecords reads /dev/kmsg, falling back to dmesg when it is not readable.
+func (m *MonitoringService) kernelRecords(ctx context.Context) (string, []KernelRecord, error) {
+	data, kerr := readKmsg()
+	if kerr == nil || data != "" {
+		return kmsgPath, ParseKmsg(data), nil
+	}
+	out, err := runCmd(ctx, m.exec, "dmesg")
+	if err != nil && out == "" {
+		return "", nil, fmt.Errorf("kmsg: %v; dmesg: %w", kerr, err)
+	}
+	return "dmesg", ParseDmesg(out), nil
+}
+
+// KernelDigest classifies kernel log events and checks for read-only data volumes.
+func (m *MonitoringService) KernelDigest(ctx context.Context) (KernelDigest, error) {
+	source, recs, err := m.kernelRecords(ctx)
+	if err != nil {
+		return KernelDigest{}, err
+	}
+	var mounts []Mount
+	if f, err := os.Open(procMountsPath); err == nil {
+		mounts, _ = ParseMounts(f)
+		f.Close()
+	}
+	return BuildKernelDigest(source, recs, mounts), nil
+}
+
+// Kernel renders the kernel event digest.
+func (m *MonitoringService) Kernel(ctx context.Context) (string, error) {
+	d, err := m.KernelDigest(ctx)
+	if err != nil {
+		return "", err
+	}
+	var boot time.Time
+	now := time.Now()
+	if up, err := HostUptime(); err == nil {
+		boot = now.Add(-up)
+	}
+	return d.Render(now, boot), nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// maxLogWalkEntries bounds how many entries a log directory walk may visit.
+	maxLogWalkEntries = 20000
+	// gzipRatioEstimate is the assumed compression ratio of a text log; the
+	// archive is estimated at size/gzipRatioEstimate when checking free space.
+	gzipRatioEstimate = 4
+	// gzipChunk is how much gzipRotate copies between cancellation checks.
+	gzipChunk = 4 * 1024 * 1024
+)
+
+// textLogRe matches plain-text log names: *.log (including docker *-json.log)
+// and numbered rotations such as *.log.1. Compressed rotations (*.log.1.gz),
+// systemd journals (*.journal) and binary logs never match.
+var textLogRe = regexp.MustCompile(`\.log(\.\d+)?$`)
+
+// LogCandidate is a log file eligible for truncation or rotation.
+type LogCandidate struct {
+	Path string
+	Size int64
+}
+
+// WithinRoots reports whether path (symlinks resolved) lies inside one of roots.
+func WithinRoots(path string, roots []string) bool {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	for _, r := range roots {
+		root, err := filepath.EvalSymlinks(r)
+		if err != nil {
+			continue
+		}
+		if resolved != root && strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanableLog reports whether info describes a regular, non-sparse text log.
+// Sparse files such as wtmp or lastlog report a size far above the blocks they
+// occupy; truncating them frees nothing and destroys login accounting.
+func CleanableLog(path string, info fs.FileInfo) bool {
+	if !info.Mode().IsRegular() || !textLogRe.MatchString(filepath.Base(path)) {
+		return false
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && st.Blocks*512 < info.Size() {
+		return false
+	}
+	return true
+}
+
+// FindLargeFiles walks roots and returns text logs (see CleanableLog) of at
+// least minSize, largest first.
+func FindLargeFiles(roots []string, minSize int64, limit int) []LogCandidate {
+	var out []LogCandidate
+	visited := 0
+	for _, root := range roots {
+		_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			visited++
+			if visited > maxLogWalkEntries {
+				return filepath.SkipAll
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || info.Size() < minSize || !CleanableLog(p, info) {
+				return nil
+			}
+			out = append(out, LogCandidate{Path: p, Size: info.Size()})
+			return nil
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Size > out[j].Size })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// logRoots are the directories log cleanup may touch: container log dirs and configured log dirs.
+func (s *SystemService) logRoots() []string {
+	roots := append([]string{}, s.cleanup.LogDirs...)
+	if s.cleanup.DockerRoot != "" {
+		roots = append(roots, filepath.Join(s.cleanup.DockerRoot, "containers"))
+	}
+	return roots
+}
+
+// LogCleanupCandidates lists the largest container json logs and files in allowlisted log dirs.
+func (s *SystemService) LogCleanupCandidates(ctx context.Context) []LogCandidate {
+	minSize := int64(s.cleanup.MinLogMB) * 1024 * 1024
+	var paths []string
+	if containers, err := s.docker.api.List(ctx, true); err == nil {
+		for _, c := range containers {
+			if info, err := s.docker.api.Inspect(ctx, c.Name()); err == nil && info.LogPath != "" {
+				paths = append(paths, info.LogPath)
+			}
+		}
+	} else if s.cleanup.DockerRoot != "" {
+		paths, _ = filepath.Glob(filepath.Join(s.cleanup.DockerRoot, "containers", "*", "*-json.log"))
+	}
+
+	seen := map[string]bool{}
+	var out []LogCandidate
+	roots := s.logRoots()
+	for _, p := range paths {
+		info, err := os.Lstat(p)
+		if err != nil || info.Size() < minSize || !CleanableLog(p, info) || !WithinRoots(p, roots) {
+			continue
+		}
+		seen[p] = true
+		out = append(out, LogCandidate{Path: p, Size: info.Size()})
+	}
+	for _, c := range FindLargeFiles(s.cleanup.LogDirs, minSize, s.cleanup.MaxCandidates) {
+		if !seen[c.Path] {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Size > out[j].Size })
+	if len(out) > s.cleanup.MaxCandidates {
+		out = out[:s.cleanup.MaxCandidates]
+	}
+	return out
+}
+
+// LogCleanupPreview renders numbered candidates for the confirmation prompt.
+func LogCleanupPreview(cands []LogCandidate, mode string) string {
+	var total int64
+	lines := make([]string, 0, len(cands)+1)
+	for i, c := range cands {
+		total += c.Size
+		lines = append(lines, fmt.Sprintf("%d. %s %s", i+1, c.Path, humanBytes(uint64(c.Size))))
+	}
+	header := fmt.Sprintf("Cleanup logs (%s, dry run): %d files, %s", mode, len(cands), humanBytes(uint64(total)))
+	if len(cands) == 0 {
+		header += "\nnothing above the size threshold"
+	}
+	return header + "\n" + strings.Join(lines, "\n")
+}
+
+// CleanupLogs truncates or gzip-rotates the given files after re-checking the allowlist.
+func (s *SystemService) CleanupLogs(ctx context.Context, mode string, paths []string) (string, error) {
+	if mode != "truncate" && mode != "gzip" {
+		return "", fmt.Errorf("unknown log cleanup mode %q", mode)
+	}
+	roots := s.logRoots()
+	var freed int64
+	lines := []string{}
+	for _, p := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		info, err := os.Lstat(p)
+		if err != nil || !CleanableLog(p, info) || !WithinRoots(p, roots) {
+			lines = append(lines, fmt.Sprintf("skip %s: not an allowed text log", p))
+			continue
+		}
+		var n int64
+		if mode == "gzip" {
+			n, err = gzipRotate(ctx, p, info.Size())
+		} else {
+			err = os.Truncate(p, 0)
+			n = info.Size()
+		}
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("fail %s: %v", p, err))
+			continue
+		}
+		freed += n
+		lines = append(lines, fmt.Sprintf("ok %s -%s", p, humanBytes(uint64(n))))
+	}
+	lines = append([]string{fmt.Sprintf("Log cleanup (%s): freed %s", mode, humanBytes(uint64(max(freed, 0))))}, lines...)
+	return strings.Join(lines, "\n"), nil
+}
+
+// gzipRotate compresses the current content next to the file, then truncates it.
+// It returns the bytes freed (original size minus archive size).
+//
+// This is copy-then-truncate: lines the writer appends between the end of the
+// copy and the truncate are lost. The window is the compression time of that
+// one file; writers holding the file open (O_APPEND) keep working afterwards.
+// Cancelling ctx mid-copy removes the partial archive and leaves the log intact.
+func gzipRotate(ctx context.Context, path string, size int64) (int64, error) {
+	var sfs syscall.Statfs_t
+	if err := syscall.Statfs(filepath.Dir(path), &sfs); err == nil {
+		need := uint64(size / gzipRatioEstimate)
+		if free := sfs.Bavail * uint64(sfs.Bsize); free < need {
+			return 0, fmt.Errorf("not enough free space for archive: need ~%s, have %s", humanBytes(need), humanBytes(free))
+		}
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	dst := fmt.Sprintf("%s.%s.gz", path, time.Now().UTC().Format("20060102-150405"))
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return 0, err
+	}
+	zw := gzip.NewWriter(out)
+	for left := size; left > 0; left -= gzipChunk {
+		err := ctx.Err()
+		if err == nil {
+			_, err = io.CopyN(zw, src, min(left, gzipChunk))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			zw.Close()
+			out.Close()
+			os.Remove(dst)
+			return 0, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return 0, err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return 0, err
+	}
+	if err := os.Truncate(path, 0); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		return size, nil
+	}
+	return size - info.Size(), nil
+}
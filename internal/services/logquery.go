@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"zckyachmd/lifeline/pkg/docker"
+)
+
+const (
+	maxLogLines     = 1000
+	maxScanLines    = 5000
+	maxGrepPattern  = 200
+	maxLogSince     = 7 * 24 * time.Hour
+	logFilterBudget = 3 * time.Second
+)
+
+// LogQuery is a validated /logs request.
+type LogQuery struct {
+	Since time.Duration
+	Lines int
+	Grep  *regexp.Regexp
+	Level string
+}
+
+// filtered reports whether lines need in-process filtering.
+func (q LogQuery) filtered() bool {
+	return q.Grep != nil || q.Level != ""
+}
+
+// logLevels ranks accepted --level values.
+var logLevels = map[string]int{"debug": 0, "info": 1, "warn": 2, "error": 3}
+
+// journalPriority maps --level to journalctl -p values.
+var journalPriority = map[string]string{"debug": "debug", "info": "info", "warn": "warning", "error": "err"}
+
+// ParseLogQuery validates [--since 15m] [--lines N] [--grep pattern] [--level warn].
+func ParseLogQuery(args []string) (LogQuery, error) {
+	q := LogQuery{Lines: 100}
+	for i := 0; i < len(args); i++ {
+		flag := args[i]
+		if i+1 >= len(args) {
+			return q, fmt.Errorf("missing value for %s", flag)
+		}
+		val := args[i+1]
+		i++
+		switch flag {
+		case "--since":
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 || d > maxLogSince {
+				return q, fmt.Errorf("--since must be a duration up to %s (e.g. 15m)", maxLogSince)
+			}
+			q.Since = d
+		case "--lines":
+			n, err := strconv.Atoi(val)
+			if err != nil || n <= 0 || n > maxLogLines {
+				return q, fmt.Errorf("--lines must be 1-%d", maxLogLines)
+			}
+			q.Lines = n
+		case "--grep":
+			if len(val) > maxGrepPattern {
+				return q, fmt.Errorf("--grep pattern too long")
+			}
+			re, err := regexp.Compile("(?i)" + val)
+			if err != nil {
+				return q, fmt.Errorf("invalid --grep pattern: %v", err)
+			}
+			q.Grep = re
+		case "--level":
+			if _, ok := logLevels[val]; !ok {
+				return q, fmt.Errorf("--level must be debug|info|warn|error")
+			}
+			q.Level = val
+		default:
+			return q, fmt.Errorf("unknown flag %s", flag)
+		}
+	}
+	return q, nil
+}
+
+var (
+	errorLineRe = regexp.MustCompile(`(?i)\b(ERR|ERROR|FATAL|PANIC|CRIT|CRITICAL)\b|level=(error|fatal|panic)`)
+	warnLineRe  = regexp.MustCompile(`(?i)\b(WRN|WARN|WARNING)\b|level=warn`)
+	infoLineRe  = regexp.MustCompile(`(?i)\b(INF|INFO|NOTICE)\b|level=info`)
+)
+
+// lineLevel guesses a log line's severity; unknown lines count as info.
+func lineLevel(line string) int {
+	switch {
+	case errorLineRe.MatchString(line):
+		return 3
+	case warnLineRe.MatchString(line):
+		return 2
+	case infoLineRe.MatchString(line):
+		return 1
+	case strings.Contains(line, "DBG") || strings.Contains(strings.ToLower(line), "debug"):
+		return 0
+	}
+	return 1
+}
+
+// FilterLogLines keeps lines matching level and grep, highlights matches
+// with »«, and returns the last q.Lines results. Filtering stops when the
+// time budget is spent; truncated reports that.
+func FilterLogLines(out string, q LogQuery, budget time.Duration) (result string, truncated bool) {
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) > maxScanLines {
+		lines = lines[len(lines)-maxScanLines:]
+	}
+	deadline := time.Now().Add(budget)
+	min := -1
+	if q.Level != "" {
+		min = logLevels[q.Level]
+	}
+	kept := make([]string, 0, q.Lines)
+	for i, line := range lines {
+		if i%500 == 0 && time.Now().After(deadline) {
+			truncated = true
+			break
+		}
+		if min >= 0 && lineLevel(line) < min {
+			continue
+		}
+		if q.Grep != nil {
+			if !q.Grep.MatchString(line) {
+				continue
+			}
+			line = q.Grep.ReplaceAllStringFunc(line, func(s string) string { return "»" + s + "«" })
+		}
+		kept = append(kept, line)
+	}
+	if len(kept) > q.Lines {
+		kept = kept[len(kept)-q.Lines:]
+	}
+	return strings.Join(kept, "\n"), truncated
+}
+
+// QueryLogs fetches logs for an allowlisted service using fixed flags and filters them.
+func (s *SystemService) QueryLogs(ctx context.Context, service string, q LogQuery) (string, error) {
+	fetch := q.Lines
+	if q.filtered() {
+		fetch = maxScanLines
+	}
+	var out string
+	var err error
+	switch strings.ToLower(service) {
+	case "cloudflared":
+		opts := docker.LogOptions{Tail: fetch}
+		if q.Since > 0 {
+			opts.Since = time.Now().Add(-q.Since)
+		}
+		out, err = s.docker.api.Logs(ctx, "cloudflared", opts)
+		if s.docker.useCLI(err) {
+			args := []string{"logs", "--tail", strconv.Itoa(fetch)}
+			if q.Since > 0 {
+				args = append(args, "--since", q.Since.String())
+			}
+			out, err = runCmd(ctx, s.exec, "docker", append(args, "cloudflared")...)
+		}
+	case "tailscale", "tailscaled":
+		out, err = runCmd(ctx, s.exec, "journalctl", journalArgs("tailscaled.service", fetch, q)...)
+	case "docker":
+		out, err = runCmd(ctx, s.exec, "journalctl", journalArgs("docker.service", fetch, q)...)
+	default:
+		return "", fmt.Errorf("service not allowed")
+	}
+	if err != nil && out == "" {
+		return "", err
+	}
+	if !q.filtered() {
+		return out, nil
+	}
+	// journald already applied the priority filter
+	if strings.ToLower(service) != "cloudflared" {
+		q.Level = ""
+	}
+	res, truncated := FilterLogLines(out, q, logFilterBudget)
+	if res == "" {
+		res = "no matching lines"
+	}
+	if truncated {
+		res += "\n[filter time budget exhausted, results partial]"
+	}
+	return res, nil
+}
+
+func journalArgs(unit string, lines int, q LogQuery) []string {
+	args := []string{"-u", unit, "--no-pager", "-o", "short-iso", "-n", strconv.Itoa(lines)}
+	if q.Since > 0 {
+		args = append(args, "--since", fmt.Sprintf("-%ds", int(q.Since.Seconds())))
+	}
+	if q.Level != "" {
+		args = append(args, "-p", journalPriority[q.Level])
+	}
+	return args
+}
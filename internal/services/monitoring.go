@@ -5,24 +5,41 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os/exec"
 	"strings"
 	"time"
 
 	"zckyachmd/lifeline/internal/api"
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/pkg/executor"
 )
 
 // MonitoringService wraps visibility operations.
 type MonitoringService struct {
-	dsm  *api.Client
-	http *http.Client
+	dsm         *api.Client
+	http        *http.Client
+	docker      *DockerRuntime
+	exec        *executor.Runner
+	crashLoops  *CrashLoopTracker
+	cfLast      *cfScrape
+	storage     config.StorageConfig
+	ups         config.UPSConfig
+	du          config.DUConfig
+	cloudflared config.CloudflaredConfig
 }
 
-// NewMonitoring creates monitoring service.
-func NewMonitoring(dsm *api.Client) *MonitoringService {
+// NewMonitoring creates monitoring service; cfg supplies storage, UPS, du and cloudflared settings.
+func NewMonitoring(dsm *api.Client, dockerRT *DockerRuntime, runner *executor.Runner, cfg *config.AppConfig) *MonitoringService {
 	return &MonitoringService{
-		dsm:  dsm,
-		http: &http.Client{Timeout: 5 * time.Second},
+		dsm:         dsm,
+		http:        &http.Client{Timeout: 5 * time.Second},
+		docker:      dockerRT,
+		exec:        runner,
+		crashLoops:  NewCrashLoopTracker(15*time.Minute, 3), // 3 restarts within 15m
+		cfLast:      &cfScrape{},
+		storage:     cfg.Storage,
+		ups:         cfg.UPS,
+		du:          cfg.DU,
+		cloudflared: cfg.Cloudflared,
 	}
 }
 
@@ -33,7 +50,12 @@ func (m *MonitoringService) Health(ctx context.Context) (string, error) {
 		return "", err
 	}
 	res, _ := m.Resources(ctx)
-	return fmt.Sprintf("DSM OK: %v\nResources:\n%s", sys["data"], res), nil
+	out := fmt.Sprintf("DSM OK: %v\nResources:\n%s", sys["data"], res)
+	out += "\n" + m.storageHealth(ctx)
+	if ddns := m.ddnsHealth(ctx); ddns != "" {
+		out += "\n" + ddns
+	}
+	return out, nil
 }
 
 // Resources reports CPU/memory/disk.
@@ -48,13 +70,22 @@ func (m *MonitoringService) Resources(ctx context.Context) (string, error) {
 // Status checks key services.
 func (m *MonitoringService) Status(ctx context.Context) (string, error) {
 	parts := []string{}
-	checks := map[string][]string{
-		"cloudflared": {"docker", "inspect", "-f", "{{.State.Status}}", "cloudflared"},
-		"tailscale":   {"systemctl", "is-active", "tailscaled.service"},
-		"docker":      {"systemctl", "is-active", "docker.service"},
+	// a running container without edge connections is reported as unhealthy
+	healthy, detail, err := m.cloudflaredHealth(ctx)
+	switch {
+	case err != nil:
+		parts = append(parts, fmt.Sprintf("cloudflared=error:%v", err))
+	case healthy:
+		parts = append(parts, fmt.Sprintf("cloudflared=%s", detail))
+	default:
+		parts = append(parts, fmt.Sprintf("cloudflared=UNHEALTHY %s", detail))
+	}
+	units := map[string]string{
+		"tailscale": "tailscaled.service",
+		"docker":    "docker.service",
 	}
-	for name, cmd := range checks {
-		out, err := runCommand(ctx, cmd)
+	for name, unit := range units {
+		out, err := runCmd(ctx, m.exec, "systemctl", "is-active", unit)
 		if err != nil {
 			parts = append(parts, fmt.Sprintf("%s=error:%v", name, err))
 			continue
@@ -64,9 +95,18 @@ func (m *MonitoringService) Status(ctx context.Context) (string, error) {
 	return strings.Join(parts, " \n"), nil
 }
 
+// StatusSummary condenses Status into a single line.
+func (m *MonitoringService) StatusSummary(ctx context.Context) string {
+	out, err := m.Status(ctx)
+	if err != nil {
+		return "status unavailable: " + err.Error()
+	}
+	return strings.Join(strings.Fields(out), " ")
+}
+
 // DiagNet runs minimal network diagnostics.
 func (m *MonitoringService) DiagNet(ctx context.Context) (string, error) {
-	output, err := runCommand(ctx, []string{"ping", "-c", "1", "1.1.1.1"})
+	output, err := runCmd(ctx, m.exec, "ping", "-c", "1", "1.1.1.1")
 	if err != nil {
 		return "", err
 	}
@@ -75,7 +115,7 @@ func (m *MonitoringService) DiagNet(ctx context.Context) (string, error) {
 
 // DiagTime checks NTP sync and clock.
 func (m *MonitoringService) DiagTime(ctx context.Context) (string, error) {
-	out, err := runCommand(ctx, []string{"timedatectl"})
+	out, err := runCmd(ctx, m.exec, "timedatectl")
 	if err != nil {
 		return "", err
 	}
@@ -96,12 +136,3 @@ func (m *MonitoringService) PublicIP(ctx context.Context) (string, error) {
 	}
 	return buf.String(), nil
 }
-
-func runCommand(ctx context.Context, parts []string) (string, error) {
-	if len(parts) == 0 {
-		return "", fmt.Errorf("empty command")
-	}
-	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
-	b, err := cmd.CombinedOutput()
-	return string(b), err
-}
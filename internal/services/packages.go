@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"zckyachmd/lifeline/internal/api"
+)
+
+// Package is an installed Synology package.
+type Package struct {
+	ID      string
+	Name    string
+	Version string
+	Status  string
+}
+
+// Running reports whether DSM considers the package started.
+func (p Package) Running() bool {
+	return p.Status == "running"
+}
+
+// ParsePackages extracts packages from a SYNO.Core.Package list payload.
+func ParsePackages(data map[string]any) []Package {
+	items := asList(data["packages"])
+	out := make([]Package, 0, len(items))
+	for _, p := range items {
+		status := asString(p["status"])
+		if add, ok := p["additional"].(map[string]any); ok && status == "" {
+			status = asString(add["status"])
+		}
+		out = append(out, Package{
+			ID:      asString(p["id"]),
+			Name:    asString(p["name"]),
+			Version: asString(p["version"]),
+			Status:  strings.ToLower(status),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Packages fetches installed packages from DSM.
+func (m *MonitoringService) Packages(ctx context.Context) ([]Package, error) {
+	return listPackages(ctx, m.dsm)
+}
+
+// PackageList renders installed packages and their running state.
+func (m *MonitoringService) PackageList(ctx context.Context) (string, error) {
+	pkgs, err := m.Packages(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(pkgs) == 0 {
+		return "No packages installed", nil
+	}
+	lines := make([]string, 0, len(pkgs))
+	for _, p := range pkgs {
+		lines = append(lines, fmt.Sprintf("%s (%s) %s", p.ID, p.Version, p.Status))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func listPackages(ctx context.Context, dsm *api.Client) ([]Package, error) {
+	resp, err := dsm.Packages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := dsmData(resp)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePackages(data), nil
+}
+
+// IsAllowedPackage checks the package restart allowlist (case-insensitive on id).
+func (s *SystemService) IsAllowedPackage(id string) bool {
+	return s.allowedPackageID(id) != ""
+}
+
+func (s *SystemService) allowedPackageID(id string) string {
+	for _, v := range s.packages {
+		if strings.EqualFold(v, id) {
+			return v
+		}
+	}
+	return ""
+}
+
+// RestartPackage stops and starts an allowlisted package and polls until it
+// runs again, bounded by the verify timeout and interval.
+func (s *SystemService) RestartPackage(ctx context.Context, id string) (string, error) {
+	pkgID := s.allowedPackageID(id)
+	if pkgID == "" {
+		return "", fmt.Errorf("package not allowed")
+	}
+	if resp, err := s.dsm.PackageControl(ctx, pkgID, "stop"); err != nil {
+		return "", fmt.Errorf("stop %s: %w", pkgID, err)
+	} else if _, err := dsmData(resp); err != nil {
+		return "", fmt.Errorf("stop %s: %w", pkgID, err)
+	}
+	if resp, err := s.dsm.PackageControl(ctx, pkgID, "start"); err != nil {
+		return "", fmt.Errorf("start %s: %w", pkgID, err)
+	} else if _, err := dsmData(resp); err != nil {
+		return "", fmt.Errorf("start %s: %w", pkgID, err)
+	}
+
+	started := time.Now()
+	pollCtx, cancel := context.WithTimeout(ctx, s.VerifyTimeout())
+	defer cancel()
+	interval := time.Duration(s.verify.IntervalSeconds) * time.Second
+	last := "unknown"
+	for {
+		pkgs, err := listPackages(pollCtx, s.dsm)
+		if err == nil {
+			for _, p := range pkgs {
+				if strings.EqualFold(p.ID, pkgID) {
+					last = p.Status
+					if p.Running() {
+						return fmt.Sprintf("%s restarted, running after %s", pkgID, time.Since(started).Round(time.Second)), nil
+					}
+				}
+			}
+		}
+		select {
+		case <-pollCtx.Done():
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", fmt.Errorf("%s did not come back up within %s (status=%s)", pkgID, s.VerifyTimeout(), last)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// PackageRestartLimit bounds a package restart, for running it as a job.
+func (s *SystemService) PackageRestartLimit() time.Duration {
+	return s.VerifyTimeout() + time.Minute
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+// maxProbeBody caps how much of an HTTP response body_match scans.
+const maxProbeBody = 256 * 1024
+
+// ProbeResult is the outcome of one probe run.
+type ProbeResult struct {
+	Name     string
+	Type     string
+	Target   string
+	OK       bool
+	Detail   string
+	Duration time.Duration
+}
+
+// ProbeService runs the configured LAN host and endpoint probes.
+type ProbeService struct {
+	probes []config.Probe
+	bodyRe map[string]*regexp.Regexp
+	exec   *executor.Runner
+}
+
+// NewProbes compiles body matchers; probes are validated by config.
+func NewProbes(probes []config.Probe, runner *executor.Runner) *ProbeService {
+	ps := &ProbeService{probes: probes, bodyRe: map[string]*regexp.Regexp{}, exec: runner}
+	for _, p := range probes {
+		if p.BodyMatch != "" {
+			ps.bodyRe[p.Name] = regexp.MustCompile(p.BodyMatch)
+		}
+	}
+	return ps
+}
+
+// Names lists configured probes in config order.
+func (ps *ProbeService) Names() []string {
+	out := make([]string, 0, len(ps.probes))
+	for _, p := range ps.probes {
+		out = append(out, p.Name)
+	}
+	return out
+}
+
+// Exists reports whether a probe is configured.
+func (ps *ProbeService) Exists(name string) bool {
+	for _, p := range ps.probes {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes the named probe, or all probes when name is empty,
+// concurrently. Results keep config order.
+func (ps *ProbeService) Run(ctx context.Context, name string) ([]ProbeResult, error) {
+	var selected []config.Probe
+	for _, p := range ps.probes {
+		if name == "" || p.Name == name {
+			selected = append(selected, p)
+		}
+	}
+	if len(selected) == 0 {
+		if name == "" {
+			return nil, errors.New("no probes configured")
+		}
+		return nil, fmt.Errorf("unknown probe %s", name)
+	}
+	results := make([]ProbeResult, len(selected))
+	var wg sync.WaitGroup
+	for i, p := range selected {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = ps.runOne(ctx, p)
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// Report runs probes and renders the pass/fail table.
+func (ps *ProbeService) Report(ctx context.Context, name string) (string, error) {
+	results, err := ps.Run(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return FormatProbes(results), nil
+}
+
+// Check returns an alert check that fires while the named probe fails.
+func (ps *ProbeService) Check(name string) func(ctx context.Context) (bool, string, error) {
+	return func(ctx context.Context) (bool, string, error) {
+		results, err := ps.Run(ctx, name)
+		if err != nil {
+			return false, "", err
+		}
+		r := results[0]
+		return !r.OK, fmt.Sprintf("probe %s (%s %s): %s", r.Name, r.Type, r.Target, r.Detail), nil
+	}
+}
+
+// FormatProbes renders one PASS/FAIL row per result with a summary line.
+func FormatProbes(results []ProbeResult) string {
+	nameW, targetW, passed := 0, 0, 0
+	for _, r := range results {
+		nameW = max(nameW, len(r.Name))
+		targetW = max(targetW, len(r.Target))
+		if r.OK {
+			passed++
+		}
+	}
+	lines := []string{fmt.Sprintf("probes: %d/%d pass", passed, len(results))}
+	for _, r := range results {
+		status := "PASS"
+		if !r.OK {
+			status = "FAIL"
+		}
+		lines = append(lines, fmt.Sprintf("%s %-*s %-4s %-*s %6s %s", status, nameW, r.Name, r.Type, targetW, r.Target,
+			r.Duration.Round(time.Millisecond), r.Detail))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (ps *ProbeService) runOne(ctx context.Context, p config.Probe) ProbeResult {
+	timeout := time.Duration(p.TimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	res := ProbeResult{Name: p.Name, Type: p.Type, Target: p.Target}
+	start := time.Now()
+	var err error
+	switch p.Type {
+	case "icmp":
+		err = pingReachable(ctx, ps.exec, p.Target, timeout)
+		res.Detail = "reply"
+	case "tcp":
+		err = tcpReachable(ctx, p.Target, timeout)
+		res.Detail = "connected"
+	case "http":
+		res.Detail, err = ps.probeHTTP(ctx, p, timeout)
+	case "tls":
+		res.Detail, err = probeTLS(ctx, p, timeout)
+	default:
+		err = fmt.Errorf("unknown probe type %s", p.Type)
+	}
+	res.Duration = time.Since(start)
+	res.OK = err == nil
+	if err != nil {
+		res.Detail = err.Error()
+	}
+	return res
+}
+
+func (ps *ProbeService) probeHTTP(ctx context.Context, p config.Probe, timeout time.Duration) (string, error) {
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: p.Insecure}, // opt-in for self-signed LAN apps
+			DisableKeepAlives: true,
+		},
+		// report the redirect itself rather than following it off the LAN
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Target, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch {
+	case p.ExpectStatus != 0 && resp.StatusCode != p.ExpectStatus:
+		return "", fmt.Errorf("HTTP %d, want %d", resp.StatusCode, p.ExpectStatus)
+	case p.ExpectStatus == 0 && resp.StatusCode >= 400:
+		return "", fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	if re := ps.bodyRe[p.Name]; re != nil {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBody))
+		if err != nil {
+			return "", fmt.Errorf("HTTP %d, read body: %w", resp.StatusCode, err)
+		}
+		if !re.Match(body) {
+			return "", fmt.Errorf("HTTP %d, body does not match %q", resp.StatusCode, p.BodyMatch)
+		}
+		return fmt.Sprintf("HTTP %d, body matches", resp.StatusCode), nil
+	}
+	return fmt.Sprintf("HTTP %d", resp.StatusCode), nil
+}
+
+// probeTLS reads the leaf certificate without verifying the chain, so
+// expiry is reported for self-signed certificates too.
+func probeTLS(ctx context.Context, p config.Probe, timeout time.Duration) (string, error) {
+	host, _, _ := net.SplitHostPort(p.Target)
+	d := tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		Config:    &tls.Config{ServerName: host, InsecureSkipVerify: true},
+	}
+	conn, err := d.DialContext(ctx, "tcp", p.Target)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return "", errors.New("no certificate presented")
+	}
+	leaf := certs[0]
+	left := time.Until(leaf.NotAfter)
+	days := int(left.Hours() / 24)
+	detail := fmt.Sprintf("%s expires %s (%dd)", leaf.Subject.CommonName, leaf.NotAfter.Format("2006-01-02"), days)
+	if left < time.Duration(p.MinDaysValid)*24*time.Hour {
+		return "", fmt.Errorf("%s, under %dd", detail, p.MinDaysValid)
+	}
+	return detail, nil
+}
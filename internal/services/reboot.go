@@ -0,0 +1,198 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+var (
+	procMountsPath = "/proc/mounts"
+	ext4SysRoot    = "/sys/fs/ext4"
+)
+
+// minFreePercent is the free space below which a mount blocks a reboot.
+const minFreePercent = 5
+
+// Mount is one /proc/mounts entry.
+type Mount struct {
+	Device  string
+	Point   string
+	FSType  string
+	Options []string
+}
+
+// ReadOnly reports whether the mount carries the ro option.
+func (m Mount) ReadOnly() bool {
+	for _, o := range m.Options {
+		if o == "ro" {
+			return true
+		}
+	}
+	return false
+}
+
+// DataVolume reports whether the mount is the root fs or a DSM /volumeN.
+func (m Mount) DataVolume() bool {
+	return m.Point == "/" || strings.HasPrefix(m.Point, "/volume")
+}
+
+// ParseMounts parses /proc/mounts content.
+func ParseMounts(r io.Reader) ([]Mount, error) {
+	var out []Mount
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		f := strings.Fields(sc.Text())
+		if len(f) < 4 {
+			continue
+		}
+		// octal escapes (\040 for space) are kept as-is; DSM mount points never use them
+		out = append(out, Mount{Device: f[0], Point: f[1], FSType: f[2], Options: strings.Split(f[3], ",")})
+	}
+	return out, sc.Err()
+}
+
+// PreflightCheck is one reboot pre-flight result.
+type PreflightCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// Preflight is the set of checks run before a reboot.
+type Preflight []PreflightCheck
+
+// Blocked reports whether any check failed.
+func (p Preflight) Blocked() bool {
+	for _, c := range p {
+		if !c.OK {
+			return true
+		}
+	}
+	return false
+}
+
+// Lines renders one "OK/FAIL name: detail" line per check.
+func (p Preflight) Lines() []string {
+	out := make([]string, 0, len(p))
+	for _, c := range p {
+		status := "OK"
+		if !c.OK {
+			status = "FAIL"
+		}
+		out = append(out, fmt.Sprintf("%s %s: %s", status, c.Name, c.Detail))
+	}
+	return out
+}
+
+// String renders the pre-flight report.
+func (p Preflight) String() string {
+	return "Reboot pre-flight:\n" + strings.Join(p.Lines(), "\n")
+}
+
+// RebootPreflight checks disk space, RAID resync, running backups and
+// filesystem errors before a reboot.
+func (m *MonitoringService) RebootPreflight(ctx context.Context) Preflight {
+	var mounts []Mount
+	if f, err := os.Open(procMountsPath); err == nil {
+		mounts, _ = ParseMounts(f)
+		f.Close()
+	}
+	return Preflight{
+		diskSpaceCheck(mounts),
+		m.raidCheck(),
+		m.backupCheck(ctx),
+		fsErrorCheck(mounts),
+	}
+}
+
+func diskSpaceCheck(mounts []Mount) PreflightCheck {
+	c := PreflightCheck{Name: "disk", OK: true}
+	var low, seen []string
+	for _, mt := range mounts {
+		if !mt.DataVolume() {
+			continue
+		}
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(mt.Point, &st); err != nil || st.Blocks == 0 {
+			continue
+		}
+		pct := float64(st.Bavail) / float64(st.Blocks) * 100
+		seen = append(seen, fmt.Sprintf("%s %.0f%% free", mt.Point, pct))
+		if pct < minFreePercent {
+			low = append(low, fmt.Sprintf("%s %.1f%% free", mt.Point, pct))
+		}
+	}
+	switch {
+	case len(low) > 0:
+		c.OK = false
+		c.Detail = "low space: " + strings.Join(low, ", ")
+	case len(seen) == 0:
+		c.Detail = "no data volumes found"
+	default:
+		c.Detail = strings.Join(seen, ", ")
+	}
+	return c
+}
+
+func (m *MonitoringService) raidCheck() PreflightCheck {
+	c := PreflightCheck{Name: "raid", OK: true, Detail: "no resync in progress"}
+	f, err := os.Open(m.storage.MDStatPath)
+	if err != nil {
+		c.Detail = "mdstat unavailable"
+		return c
+	}
+	defer f.Close()
+	arrays, _ := ParseMDStat(f)
+	var busy []string
+	for _, a := range arrays {
+		if a.Rebuilding() {
+			busy = append(busy, fmt.Sprintf("%s %s %.1f%%", a.Name, a.Action, a.Progress))
+		}
+	}
+	if len(busy) > 0 {
+		c.OK = false
+		c.Detail = strings.Join(busy, ", ")
+	}
+	return c
+}
+
+func (m *MonitoringService) backupCheck(ctx context.Context) PreflightCheck {
+	c := PreflightCheck{Name: "backups", OK: true, Detail: "none running"}
+	active, err := m.ActiveBackups(ctx)
+	switch {
+	case err != nil:
+		c.Detail = fmt.Sprintf("state unknown (%v)", err)
+	case len(active) > 0:
+		c.OK = false
+		c.Detail = "running: " + strings.Join(active, ", ")
+	}
+	return c
+}
+
+// fsErrorCheck flags ext4 error counters and data volumes remounted read-only.
+func fsErrorCheck(mounts []Mount) PreflightCheck {
+	c := PreflightCheck{Name: "filesystems", OK: true, Detail: "no errors recorded"}
+	var problems []string
+	counters, _ := filepath.Glob(filepath.Join(ext4SysRoot, "*", "errors_count"))
+	for _, p := range counters {
+		if n := readUint(p); n > 0 {
+			problems = append(problems, fmt.Sprintf("%s ext4 errors=%d", filepath.Base(filepath.Dir(p)), n))
+		}
+	}
+	for _, mt := range mounts {
+		if mt.DataVolume() && mt.ReadOnly() {
+			problems = append(problems, fmt.Sprintf("%s mounted read-only", mt.Point))
+		}
+	}
+	if len(problems) > 0 {
+		c.OK = false
+		c.Detail = strings.Join(problems, ", ")
+	}
+	return c
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"zckyachmd/lifeline/internal/config"
+)
+
+// maxStepOutput caps the check output kept per runbook step.
+const maxStepOutput = 300
+
+// RunbookService runs declared runbooks against existing checks and allowlisted actions.
+type RunbookService struct {
+	monitor *MonitoringService
+	system  *SystemService
+	books   map[string]config.Runbook
+}
+
+// NewRunbooks indexes runbooks by name; they are validated by config.
+func NewRunbooks(m *MonitoringService, sys *SystemService, books []config.Runbook) *RunbookService {
+	idx := make(map[string]config.Runbook, len(books))
+	for _, b := range books {
+		idx[b.Name] = b
+	}
+	return &RunbookService{monitor: m, system: sys, books: idx}
+}
+
+// List renders runbook names with descriptions.
+func (r *RunbookService) List() string {
+	if len(r.books) == 0 {
+		return "no runbooks configured"
+	}
+	names := make([]string, 0, len(r.books))
+	for n := range r.books {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, n := range names {
+		b := r.books[n]
+		lines = append(lines, fmt.Sprintf("%s (%d steps) %s", n, len(b.Steps), b.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Exists reports whether a runbook is declared.
+func (r *RunbookService) Exists(name string) bool {
+	_, ok := r.books[name]
+	return ok
+}
+
+// Plan renders the numbered steps of a runbook.
+func (r *RunbookService) Plan(name string) (string, error) {
+	b, ok := r.books[name]
+	if !ok {
+		return "", fmt.Errorf("unknown runbook %q", name)
+	}
+	lines := []string{fmt.Sprintf("Runbook %s: %s", b.Name, b.Description)}
+	for i, st := range b.Steps {
+		lines = append(lines, fmt.Sprintf("%d. %s", i+1, DescribeStep(st)))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// DescribeStep renders a step as "kind target [if cond]".
+func DescribeStep(st config.RunbookStep) string {
+	var s string
+	switch {
+	case st.Check != "":
+		s = "check " + st.Check
+	case st.Action != "":
+		s = "action " + st.Action
+	case st.Wait != "":
+		s = "wait " + st.Wait
+	case st.Verify != "":
+		s = "verify " + st.Verify
+	}
+	if st.IfHealthy != "" {
+		s += " (if " + st.IfHealthy + " healthy)"
+	}
+	if st.IfUnhealthy != "" {
+		s += " (if " + st.IfUnhealthy + " unhealthy)"
+	}
+	return s
+}
+
+// Run executes a runbook step by step. progress receives the transcript so
+// far after every change. The run stops at the first failed action or
+// verification; it never escalates beyond the declared steps.
+func (r *RunbookService) Run(ctx context.Context, name string, progress func(transcript string)) (string, error) {
+	b, ok := r.books[name]
+	if !ok {
+		return "", fmt.Errorf("unknown runbook %q", name)
+	}
+	lines := []string{fmt.Sprintf("Runbook %s", b.Name)}
+	emit := func() {
+		if progress != nil {
+			progress(strings.Join(lines, "\n"))
+		}
+	}
+	total := len(b.Steps)
+	for i, st := range b.Steps {
+		prefix := fmt.Sprintf("%d/%d %s", i+1, total, DescribeStep(st))
+		if ctx.Err() != nil {
+			lines = append(lines, prefix+": not run")
+			return strings.Join(lines, "\n"), ctx.Err()
+		}
+		if skip, why := r.skipStep(ctx, st); skip {
+			lines = append(lines, prefix+": skipped ("+why+")")
+			emit()
+			continue
+		}
+		lines = append(lines, prefix+": running...")
+		emit()
+		detail, err := r.runStep(ctx, st)
+		if err != nil {
+			lines[len(lines)-1] = fmt.Sprintf("%s: FAILED %v", prefix, err)
+			return strings.Join(lines, "\n"), fmt.Errorf("stopped at step %d: %w", i+1, err)
+		}
+		lines[len(lines)-1] = prefix + ": ok"
+		if detail != "" {
+			lines[len(lines)-1] += " " + detail
+		}
+		emit()
+	}
+	lines = append(lines, "completed")
+	return strings.Join(lines, "\n"), nil
+}
+
+func (r *RunbookService) skipStep(ctx context.Context, st config.RunbookStep) (bool, string) {
+	switch {
+	case st.IfHealthy != "":
+		if ok, detail := r.system.ServiceHealth(ctx, st.IfHealthy); !ok {
+			return true, st.IfHealthy + " " + detail
+		}
+	case st.IfUnhealthy != "":
+		if ok, detail := r.system.ServiceHealth(ctx, st.IfUnhealthy); ok {
+			return true, st.IfUnhealthy + " " + detail
+		}
+	}
+	return false, ""
+}
+
+func (r *RunbookService) runStep(ctx context.Context, st config.RunbookStep) (string, error) {
+	switch {
+	case st.Check != "":
+		out, err := r.check(ctx, st.Check)
+		if err != nil {
+			// checks inform the operator; they never stop a run
+			return "(error: " + err.Error() + ")", nil
+		}
+		return "\n" + clip(strings.TrimSpace(out), maxStepOutput), nil
+	case st.Wait != "":
+		d, _ := time.ParseDuration(st.Wait)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(d):
+			return "", nil
+		}
+	case st.Verify != "":
+		res := r.system.VerifyRestart(ctx, st.Verify, nil)
+		if !res.Healthy {
+			return "", fmt.Errorf("%s not healthy after %s: %s", st.Verify, res.Elapsed, res.Detail)
+		}
+		return fmt.Sprintf("(healthy after %s)", res.Elapsed), nil
+	case st.Action != "":
+		out, err := r.action(ctx, strings.Fields(st.Action))
+		if err != nil {
+			return "", err
+		}
+		return "\n" + clip(strings.TrimSpace(out), maxStepOutput), nil
+	}
+	return "", fmt.Errorf("empty step")
+}
+
+func (r *RunbookService) check(ctx context.Context, name string) (string, error) {
+	switch name {
+	case "health":
+		return r.monitor.Health(ctx)
+	case "status":
+		return r.monitor.Status(ctx)
+	case "resources":
+		return r.monitor.Resources(ctx)
+	case "storage":
+		return r.monitor.Storage(ctx)
+	case "backups":
+		return r.monitor.Backups(ctx)
+	case "ups":
+		return r.monitor.UPSReport(ctx)
+	case "diag_net":
+		return r.monitor.DiagNet(ctx)
+	case "diag_time":
+		return r.monitor.DiagTime(ctx)
+	case "ip":
+		return r.monitor.PublicIP(ctx)
+	}
+	return "", fmt.Errorf("unknown check %s", name)
+}
+
+// action re-checks the allowlists at run time before acting.
+func (r *RunbookService) action(ctx context.Context, f []string) (string, error) {
+	switch {
+	case len(f) == 2 && f[0] == "restart" && r.system.IsRestartable(f[1]):
+		return r.system.RestartService(ctx, f[1])
+	case len(f) == 3 && f[0] == "pkg" && f[1] == "restart" && r.system.IsAllowedPackage(f[2]):
+		return r.system.RestartPackage(ctx, f[2])
+	case len(f) == 2 && f[0] == "ddns" && f[1] == "update":
+		return r.system.UpdateDDNS(ctx)
+	}
+	return "", fmt.Errorf("action %q not allowed", strings.Join(f, " "))
+}
+
+// clip shortens s to n bytes on a rune boundary.
+func clip(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// procUptimePath is read for host uptime and boot time.
+var procUptimePath = "/proc/uptime"
+
+// Run states recorded in the marker file.
+const (
+	RunRunning         = "running"
+	RunStopped         = "stopped"
+	RunRebootScheduled = "reboot_scheduled" // snapshot and countdown in progress
+	RunRebooting       = "rebooting"
+)
+
+// RunState is the persisted lifecycle marker of a bot process.
+type RunState struct {
+	State     string    `json:"state"`
+	Version   string    `json:"version"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RunMarker records whether the bot stopped cleanly, crashed or rebooted the host.
+type RunMarker struct {
+	path string
+	mu   sync.Mutex
+	cur  RunState
+}
+
+// NewRunMarker uses the marker file at path.
+func NewRunMarker(path string) *RunMarker {
+	return &RunMarker{path: path}
+}
+
+// Start returns the previous run's marker (ok=false when none exists) and
+// records this process as running.
+func (r *RunMarker) Start(version string) (prev RunState, ok bool, err error) {
+	if b, readErr := os.ReadFile(r.path); readErr == nil {
+		ok = json.Unmarshal(b, &prev) == nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	r.cur = RunState{State: RunRunning, Version: version, PID: os.Getpid(), StartedAt: now, UpdatedAt: now}
+	return prev, ok, r.write()
+}
+
+// MarkStopped records a clean shutdown, keeping a pending reboot marker intact.
+func (r *RunMarker) MarkStopped() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cur.State == RunRebooting {
+		return nil
+	}
+	r.cur.State = RunStopped
+	return r.write()
+}
+
+// MarkRebootScheduled records a confirmed reboot that has not run yet, so a
+// crash during the snapshot or countdown is visible on the next start.
+func (r *RunMarker) MarkRebootScheduled() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cur.State = RunRebootScheduled
+	return r.write()
+}
+
+// MarkRunning clears a scheduled or failed reboot. Any other state is left
+// alone, so a clean-shutdown marker is not overwritten.
+func (r *RunMarker) MarkRunning() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cur.State != RunRebootScheduled && r.cur.State != RunRebooting {
+		return nil
+	}
+	r.cur.State = RunRunning
+	return r.write()
+}
+
+// MarkRebooting records that the bot is about to reboot the host.
+func (r *RunMarker) MarkRebooting() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cur.State = RunRebooting
+	return r.write()
+}
+
+func (r *RunMarker) write() error {
+	r.cur.UpdatedAt = time.Now().UTC()
+	b, err := json.MarshalIndent(r.cur, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, b, 0o600)
+}
+
+// DescribePreviousRun explains how the last run ended, using the host boot
+// time to tell an initiated reboot from an unexpected host restart.
+func DescribePreviousRun(prev RunState, ok bool, bootTime time.Time) string {
+	if !ok {
+		return "first start (no previous run recorded)"
+	}
+	hostRebooted := !bootTime.IsZero() && bootTime.After(prev.UpdatedAt)
+	switch prev.State {
+	case RunStopped:
+		if hostRebooted {
+			return "clean shutdown, host rebooted since"
+		}
+		return "clean shutdown"
+	case RunRebootScheduled:
+		if hostRebooted {
+			return "host went down while a reboot was scheduled"
+		}
+		return "bot stopped while a reboot was scheduled; the reboot did not run"
+	case RunRebooting:
+		if hostRebooted {
+			return "host reboot initiated by LIFELINE completed"
+		}
+		return "reboot was initiated but the host did not restart"
+	case RunRunning:
+		if hostRebooted {
+			return "host went down unexpectedly (no clean shutdown)"
+		}
+		return "previous run crashed or was killed"
+	}
+	return fmt.Sprintf("unknown previous state %q", prev.State)
+}
+
+// HostUptime reads the host uptime from /proc/uptime.
+func HostUptime() (time.Duration, error) {
+	b, err := os.ReadFile(procUptimePath)
+	if err != nil {
+		return 0, err
+	}
+	f := strings.Fields(string(b))
+	if len(f) == 0 {
+		return 0, errors.New("empty uptime")
+	}
+	secs, err := strconv.ParseFloat(f[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+// writeFileAtomic writes data to a temp file, syncs it and renames it over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp." + strconv.Itoa(os.Getpid())
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
@@ -14,11 +14,12 @@ import (
 type SnapshotService struct {
 	monitor *MonitoringService
 	system  *SystemService
+	probes  *ProbeService
 }
 
 // NewSnapshot constructs service.
-func NewSnapshot(m *MonitoringService, sys *SystemService) *SnapshotService {
-	return &SnapshotService{monitor: m, system: sys}
+func NewSnapshot(m *MonitoringService, sys *SystemService, probes *ProbeService) *SnapshotService {
+	return &SnapshotService{monitor: m, system: sys, probes: probes}
 }
 
 // Build generates zip buffer with diagnostics.
@@ -39,10 +40,27 @@ func (s *SnapshotService) Build(ctx context.Context) ([]byte, error) {
 	res, _ := s.monitor.Resources(ctx)
 	status, _ := s.monitor.Status(ctx)
 	diag, _ := s.monitor.DiagNet(ctx)
+	storage, err := s.monitor.Storage(ctx)
+	if err != nil {
+		storage = err.Error()
+	}
 	_ = addFile("health.txt", health)
 	_ = addFile("resources.txt", res)
 	_ = addFile("status.txt", status)
 	_ = addFile("diag-net.txt", diag)
+	_ = addFile("storage.txt", storage)
+	kernel, err := s.monitor.Kernel(ctx)
+	if err != nil {
+		kernel = err.Error()
+	}
+	_ = addFile("kernel.txt", kernel)
+	if len(s.probes.Names()) > 0 {
+		probes, err := s.probes.Report(ctx, "")
+		if err != nil {
+			probes = err.Error()
+		}
+		_ = addFile("probes.txt", probes)
+	}
 
 	// Attach last logs for key services
 	if out, err := s.system.TailLogs(ctx, "tailscale", 100); err == nil {
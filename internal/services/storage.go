@@ -0,0 +1,311 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MDArray describes one Linux software RAID array from /proc/mdstat.
+type MDArray struct {
+	Name     string
+	State    string
+	Level    string
+	Devices  []string
+	Failed   []string
+	Total    int
+	Up       int
+	Map      string
+	Action   string
+	Progress float64
+}
+
+// Degraded reports missing or failed members.
+func (a MDArray) Degraded() bool {
+	return len(a.Failed) > 0 || (a.Total > 0 && a.Up < a.Total) || strings.Contains(a.Map, "_")
+}
+
+// Rebuilding reports a recovery, resync or reshape. Scheduled scrubs
+// (check/repair) are routine and not treated as problems.
+func (a MDArray) Rebuilding() bool {
+	switch a.Action {
+	case "recovery", "resync", "reshape":
+		return true
+	}
+	return false
+}
+
+var (
+	mdHeaderRe   = regexp.MustCompile(`^(md\d+)\s*:\s*(\S+)\s+(.*)$`)
+	mdStatusRe   = regexp.MustCompile(`\[(\d+)/(\d+)\]\s+\[([U_]+)\]`)
+	mdProgressRe = regexp.MustCompile(`(recovery|resync|reshape|check)\s*=\s*([\d.]+)%`)
+)
+
+// ParseMDStat parses /proc/mdstat content.
+func ParseMDStat(r io.Reader) ([]MDArray, error) {
+	var arrays []MDArray
+	var cur *MDArray
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if h := mdHeaderRe.FindStringSubmatch(line); h != nil {
+			arrays = append(arrays, MDArray{Name: h[1], State: h[2]})
+			cur = &arrays[len(arrays)-1]
+			for _, f := range strings.Fields(h[3]) {
+				switch {
+				case strings.HasPrefix(f, "raid"), f == "linear":
+					cur.Level = f
+				case f == "(auto-read-only)", f == "(read-only)":
+					cur.State += " " + strings.Trim(f, "()")
+				case strings.Contains(f, "["):
+					dev := f[:strings.Index(f, "[")]
+					cur.Devices = append(cur.Devices, dev)
+					if strings.HasSuffix(f, "(F)") {
+						cur.Failed = append(cur.Failed, dev)
+					}
+				}
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if s := mdStatusRe.FindStringSubmatch(line); s != nil {
+			cur.Total, _ = strconv.Atoi(s[1])
+			cur.Up, _ = strconv.Atoi(s[2])
+			cur.Map = "[" + s[3] + "]"
+		}
+		if p := mdProgressRe.FindStringSubmatch(line); p != nil {
+			cur.Action = p[1]
+			cur.Progress, _ = strconv.ParseFloat(p[2], 64)
+		}
+		if strings.TrimSpace(line) == "" {
+			cur = nil
+		}
+	}
+	return arrays, sc.Err()
+}
+
+// StorageReport combines DSM storage state with local md arrays.
+type StorageReport struct {
+	Pools    []StorageItem
+	Volumes  []StorageItem
+	Disks    []StorageItem
+	Arrays   []MDArray
+	Problems []string
+}
+
+// StorageItem is a pool, volume or disk as reported by DSM.
+type StorageItem struct {
+	ID     string
+	Kind   string
+	Status string
+	Detail string
+}
+
+// Degraded is true when any pool, volume, disk or array needs attention.
+func (r StorageReport) Degraded() bool {
+	return len(r.Problems) > 0
+}
+
+// ParseDSMStorage extracts pools, volumes and disks from SYNO.Storage.CGI.Storage load_info.
+func ParseDSMStorage(data map[string]any) StorageReport {
+	var rep StorageReport
+	for _, p := range asList(data["storagePools"]) {
+		detail := asString(p["raidType"])
+		if dt := asString(p["device_type"]); dt != "" {
+			detail = strings.TrimSpace(detail + " " + dt)
+		}
+		if prog, ok := p["progress"].(map[string]any); ok {
+			if pct := asString(prog["percent"]); pct != "" && pct != "-1" {
+				detail += fmt.Sprintf(" %s %s%%", asString(prog["step"]), pct)
+			}
+		}
+		rep.Pools = append(rep.Pools, StorageItem{ID: asString(p["id"]), Kind: "pool", Status: asString(p["status"]), Detail: detail})
+	}
+	for _, v := range asList(data["volumes"]) {
+		detail := asString(v["vol_path"])
+		if size, ok := v["size"].(map[string]any); ok {
+			total, used := asFloat(size["total"]), asFloat(size["used"])
+			if total > 0 {
+				detail += fmt.Sprintf(" used=%.0f%%", used/total*100)
+			}
+		}
+		rep.Volumes = append(rep.Volumes, StorageItem{ID: asString(v["id"]), Kind: "volume", Status: asString(v["status"]), Detail: detail})
+	}
+	for _, d := range asList(data["disks"]) {
+		detail := strings.TrimSpace(asString(d["model"]))
+		if smart := asString(d["smart_status"]); smart != "" {
+			detail += " smart=" + smart
+		}
+		if temp := asString(d["temp"]); temp != "" {
+			detail += " temp=" + temp + "C"
+		}
+		rep.Disks = append(rep.Disks, StorageItem{ID: asString(d["id"]), Kind: "disk", Status: asString(d["status"]), Detail: detail})
+	}
+	return rep
+}
+
+func (r *StorageReport) collectProblems() {
+	r.Problems = nil
+	for _, group := range [][]StorageItem{r.Pools, r.Volumes, r.Disks} {
+		for _, it := range group {
+			if it.Status != "" && it.Status != "normal" {
+				r.Problems = append(r.Problems, fmt.Sprintf("%s %s is %s", it.Kind, it.ID, strings.ToUpper(it.Status)))
+			}
+			if it.Kind == "disk" && strings.Contains(it.Detail, "smart=") && !strings.Contains(it.Detail, "smart=normal") {
+				r.Problems = append(r.Problems, fmt.Sprintf("disk %s SMART warning", it.ID))
+			}
+		}
+	}
+	for _, a := range r.Arrays {
+		if a.Degraded() {
+			msg := fmt.Sprintf("%s %s DEGRADED %s", a.Name, a.Level, a.Map)
+			if len(a.Failed) > 0 {
+				msg += " failed=" + strings.Join(a.Failed, ",")
+			}
+			r.Problems = append(r.Problems, msg)
+		}
+		if a.Rebuilding() {
+			r.Problems = append(r.Problems, fmt.Sprintf("%s %s %.1f%%", a.Name, a.Action, a.Progress))
+		}
+		if strings.HasPrefix(a.State, "inactive") {
+			r.Problems = append(r.Problems, fmt.Sprintf("%s INACTIVE", a.Name))
+		}
+	}
+}
+
+// StorageState gathers storage state from DSM and falls back to /proc/mdstat.
+func (m *MonitoringService) StorageState(ctx context.Context) (StorageReport, error) {
+	var rep StorageReport
+	var dsmErr, mdErr error
+
+	if resp, err := m.dsm.StorageInfo(ctx); err != nil {
+		dsmErr = err
+	} else if data, err := dsmData(resp); err != nil {
+		dsmErr = err
+	} else {
+		rep = ParseDSMStorage(data)
+	}
+
+	if f, err := os.Open(m.storage.MDStatPath); err != nil {
+		mdErr = err
+	} else {
+		rep.Arrays, mdErr = ParseMDStat(f)
+		f.Close()
+	}
+
+	if dsmErr != nil && mdErr != nil {
+		return rep, fmt.Errorf("storage unavailable: dsm: %v; mdstat: %v", dsmErr, mdErr)
+	}
+	rep.collectProblems()
+	return rep, nil
+}
+
+// Storage renders pools, volumes, disks and md arrays with problems first.
+func (m *MonitoringService) Storage(ctx context.Context) (string, error) {
+	rep, err := m.StorageState(ctx)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if rep.Degraded() {
+		sb.WriteString("!!! STORAGE NEEDS ATTENTION !!!\n")
+		for _, p := range rep.Problems {
+			sb.WriteString("- " + p + "\n")
+		}
+		sb.WriteString("\n")
+	} else {
+		sb.WriteString("Storage: OK\n")
+	}
+	for _, group := range [][]StorageItem{rep.Pools, rep.Volumes, rep.Disks} {
+		for _, it := range group {
+			fmt.Fprintf(&sb, "%s %s: %s %s\n", it.Kind, it.ID, it.Status, it.Detail)
+		}
+	}
+	for _, a := range rep.Arrays {
+		fmt.Fprintf(&sb, "%s: %s %s %s %d/%d", a.Name, a.State, a.Level, a.Map, a.Up, a.Total)
+		if a.Action != "" {
+			fmt.Fprintf(&sb, " %s %.1f%%", a.Action, a.Progress)
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// storageHealth is a one-line storage summary for /health.
+func (m *MonitoringService) storageHealth(ctx context.Context) string {
+	rep, err := m.StorageState(ctx)
+	if err != nil {
+		return fmt.Sprintf("Storage: error: %v", err)
+	}
+	if rep.Degraded() {
+		return "Storage: DEGRADED: " + strings.Join(rep.Problems, "; ")
+	}
+	return "Storage: OK"
+}
+
+// smartAttributes are the SMART attributes worth showing in an emergency.
+var smartAttributes = map[string]bool{
+	"Reallocated_Sector_Ct":   true,
+	"Current_Pending_Sector":  true,
+	"Offline_Uncorrectable":   true,
+	"Reported_Uncorrect":      true,
+	"UDMA_CRC_Error_Count":    true,
+	"Temperature_Celsius":     true,
+	"Power_On_Hours":          true,
+	"Seek_Error_Rate":         true,
+	"Spin_Retry_Count":        true,
+	"Reallocated_Event_Count": true,
+}
+
+// IsAllowedDisk checks the smartctl device allowlist.
+func (m *MonitoringService) IsAllowedDisk(dev string) bool {
+	for _, d := range m.storage.SmartDisks {
+		if d == dev {
+			return true
+		}
+	}
+	return false
+}
+
+// Smart reports overall health and key attributes for an allowlisted disk.
+func (m *MonitoringService) Smart(ctx context.Context, dev string) (string, error) {
+	if m.storage.SmartctlPath == "" {
+		return "", fmt.Errorf("smartctl not configured")
+	}
+	if !m.IsAllowedDisk(dev) {
+		return "", fmt.Errorf("disk not allowed")
+	}
+	out, err := runCmd(ctx, m.exec, "smartctl", "-H", "-A", dev)
+	if out == "" && err != nil {
+		return "", err
+	}
+	lines := []string{dev}
+	inTable := false
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "overall-health") || strings.HasPrefix(line, "SMART Health Status") {
+			lines = append(lines, strings.TrimSpace(line))
+			continue
+		}
+		if strings.HasPrefix(line, "ID#") {
+			inTable = true
+			continue
+		}
+		f := strings.Fields(line)
+		if !inTable || len(f) < 10 || !smartAttributes[f[1]] {
+			continue
+		}
+		warn := ""
+		if f[8] != "-" {
+			warn = " FAILING:" + f[8]
+		}
+		lines = append(lines, fmt.Sprintf("%s=%s%s", f[1], f[9], warn))
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -3,37 +3,99 @@ package services
 import (
 	"context"
 	"fmt"
-	"os/exec"
+	"net/http"
 	"strings"
 	"time"
+
+	"zckyachmd/lifeline/internal/api"
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/pkg/executor"
 )
 
+// cmdTimeout bounds ordinary external commands.
+const cmdTimeout = 10 * time.Second
+
 // SystemService wraps controlled system actions.
-type SystemService struct{}
+type SystemService struct {
+	dsm         *api.Client
+	docker      *DockerRuntime
+	exec        *executor.Runner
+	http        *http.Client
+	packages    []string
+	containers  []string
+	stacks      []config.ComposeStack
+	cleanup     config.CleanupConfig
+	verify      config.VerifyConfig
+	cloudflared config.CloudflaredConfig
+	wake        config.WakeConfig
+}
+
+// NewSystem creates system action service. cfg supplies the package,
+// container and compose stack allowlists plus cleanup, verify and wake settings.
+func NewSystem(dsm *api.Client, dockerRT *DockerRuntime, runner *executor.Runner, cfg *config.AppConfig) *SystemService {
+	return &SystemService{
+		dsm:         dsm,
+		docker:      dockerRT,
+		exec:        runner,
+		http:        &http.Client{Timeout: 5 * time.Second},
+		packages:    cfg.Packages.Allowed,
+		containers:  cfg.Containers.RestartAllowed,
+		stacks:      cfg.Compose,
+		cleanup:     cfg.Cleanup,
+		verify:      cfg.Verify,
+		cloudflared: cfg.Cloudflared,
+		wake:        cfg.Wake,
+	}
+}
 
 // RestartService restarts a known service via controlled adapters.
 func (s *SystemService) RestartService(ctx context.Context, service string) (string, error) {
 	name := strings.ToLower(service)
 	switch name {
 	case "cloudflared":
-		return runCmd(ctx, "docker", "restart", "cloudflared")
+		return s.docker.Restart(ctx, "cloudflared")
 	case "tailscale", "tailscaled":
-		return runCmd(ctx, "systemctl", "restart", "tailscaled.service")
+		return runCmd(ctx, s.exec, "systemctl", "restart", "tailscaled.service")
 	case "docker":
-		return runCmd(ctx, "systemctl", "restart", "docker.service")
+		return runCmd(ctx, s.exec, "systemctl", "restart", "docker.service")
 	default:
+		if s.isAllowedContainer(service) {
+			return s.docker.Restart(ctx, service)
+		}
+		if stack, ok := s.stack(service); ok {
+			return s.restartStack(ctx, stack)
+		}
 		return "", fmt.Errorf("service not allowed")
 	}
 }
 
-// Cleanup performs docker prune scoped action.
-func (s *SystemService) Cleanup(ctx context.Context) (string, error) {
-	return runCmd(ctx, "docker", "system", "prune", "-f")
+// RestartLimit bounds a restart of service plus its health verification,
+// for running both as one job.
+func (s *SystemService) RestartLimit(service string) time.Duration {
+	if st, ok := s.stack(service); ok {
+		return time.Duration(len(ComposeSteps(st)))*composeTimeout + time.Minute
+	}
+	return s.VerifyTimeout() + 2*time.Minute
+}
+
+// IsRestartable reports whether /restart accepts the name (built-in service, allowed container or compose stack).
+func (s *SystemService) IsRestartable(name string) bool {
+	_, isStack := s.stack(name)
+	return allowedService(name) || s.isAllowedContainer(name) || isStack
+}
+
+func (s *SystemService) isAllowedContainer(name string) bool {
+	for _, c := range s.containers {
+		if c == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Reboot reboots host as last resort.
 func (s *SystemService) Reboot(ctx context.Context) (string, error) {
-	return runCmd(ctx, "systemctl", "reboot")
+	return runCmd(ctx, s.exec, "systemctl", "reboot")
 }
 
 // TailLogs returns last lines of a service.
@@ -44,36 +106,32 @@ func (s *SystemService) TailLogs(ctx context.Context, service string, lines int)
 	name := strings.ToLower(service)
 	switch name {
 	case "cloudflared":
-		return runCmd(ctx, "docker", "logs", "--tail", fmt.Sprintf("%d", lines), "cloudflared")
+		return s.docker.Logs(ctx, "cloudflared", lines)
 	case "tailscale", "tailscaled":
-		cmd := exec.CommandContext(ctx, "journalctl", "-u", "tailscaled.service", "-n", fmt.Sprintf("%d", lines))
-		b, err := cmd.CombinedOutput()
-		return string(b), err
+		return runCmd(ctx, s.exec, "journalctl", "-u", "tailscaled.service", "--no-pager", "-n", fmt.Sprintf("%d", lines))
 	case "docker":
-		cmd := exec.CommandContext(ctx, "journalctl", "-u", "docker.service", "-n", fmt.Sprintf("%d", lines))
-		b, err := cmd.CombinedOutput()
-		return string(b), err
+		return runCmd(ctx, s.exec, "journalctl", "-u", "docker.service", "--no-pager", "-n", fmt.Sprintf("%d", lines))
 	default:
 		return "", fmt.Errorf("service not allowed")
 	}
 }
 
-func runCmd(ctx context.Context, name string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-	cmd := exec.CommandContext(ctx, name, args...)
-	b, err := cmd.CombinedOutput()
-	return string(b), err
+// runCmd runs an allowlisted binary with the default timeout and returns its output.
+func runCmd(ctx context.Context, ex *executor.Runner, name string, args ...string) (string, error) {
+	return runCmdTimeout(ctx, ex, cmdTimeout, name, args...)
 }
 
-func allowedService(name string) bool {
-	allowed := []string{"tailscale", "tailscaled", "cloudflared", "docker"}
-	for _, v := range allowed {
-		if v == strings.ToLower(name) {
-			return true
-		}
+func runCmdTimeout(ctx context.Context, ex *executor.Runner, timeout time.Duration, name string, args ...string) (string, error) {
+	res, err := ex.Run(ctx, timeout, name, args...)
+	out := res.Output()
+	if res.Truncated {
+		out += "\n[output truncated]"
 	}
-	return false
+	return out, err
+}
+
+func allowedService(name string) bool {
+	return config.IsBuiltinService(name)
 }
 
 // IsAllowedService exposes allowlist for external checks.
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+const (
+	// netcheckTimeout bounds `tailscale netcheck`, which runs in the update
+	// loop; a healthy probe finishes in a few seconds.
+	netcheckTimeout   = 8 * time.Second
+	keyExpiryWarning  = 7 * 24 * time.Hour
+	maxTailscalePeers = 15
+	maxDERPRegions    = 5
+)
+
+// TailscalePeer is a node in `tailscale status --json` (Self or Peer entries).
+type TailscalePeer struct {
+	HostName     string
+	DNSName      string
+	OS           string
+	TailscaleIPs []string
+	Online       bool
+	Active       bool
+	Expired      bool
+	KeyExpiry    *time.Time
+	Relay        string
+	CurAddr      string
+}
+
+// TailscaleStatus is the subset of `tailscale status --json` we report.
+type TailscaleStatus struct {
+	Version      string
+	BackendState string
+	AuthURL      string
+	Health       []string
+	Self         TailscalePeer
+	Peers        []TailscalePeer
+}
+
+// ParseTailscaleStatus decodes `tailscale status --json`, flattening peers sorted by host name.
+func ParseTailscaleStatus(b []byte) (TailscaleStatus, error) {
+	var raw struct {
+		Version      string
+		BackendState string
+		AuthURL      string
+		Health       []string
+		Self         *TailscalePeer
+		Peer         map[string]TailscalePeer
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return TailscaleStatus{}, fmt.Errorf("parse tailscale status: %w", err)
+	}
+	st := TailscaleStatus{Version: raw.Version, BackendState: raw.BackendState, AuthURL: raw.AuthURL, Health: raw.Health}
+	if raw.Self != nil {
+		st.Self = *raw.Self
+	}
+	for _, p := range raw.Peer {
+		st.Peers = append(st.Peers, p)
+	}
+	sort.Slice(st.Peers, func(i, j int) bool { return st.Peers[i].HostName < st.Peers[j].HostName })
+	return st, nil
+}
+
+// Problems lists login and key conditions that need operator attention.
+func (st TailscaleStatus) Problems(now time.Time) []string {
+	var out []string
+	switch st.BackendState {
+	case "Running":
+	case "NeedsLogin":
+		msg := "NeedsLogin: node is logged out"
+		if st.AuthURL != "" {
+			msg += ", login at " + st.AuthURL
+		}
+		out = append(out, msg)
+	case "NeedsMachineAuth":
+		out = append(out, "NeedsMachineAuth: approve the node in the admin console")
+	case "":
+		out = append(out, "backend state unknown")
+	default:
+		out = append(out, "backend "+st.BackendState)
+	}
+	switch exp := st.Self.KeyExpiry; {
+	case st.Self.Expired || (exp != nil && !exp.After(now)):
+		out = append(out, "node key expired: re-authenticate with tailscale up")
+	case exp != nil && exp.Sub(now) < keyExpiryWarning:
+		out = append(out, fmt.Sprintf("node key expires in %s", exp.Sub(now).Round(time.Hour)))
+	}
+	return out
+}
+
+// DERPLatency is one region line of `tailscale netcheck`.
+type DERPLatency struct {
+	Region  string
+	Latency string
+	Name    string
+}
+
+// Netcheck is the parsed text report of `tailscale netcheck`.
+type Netcheck struct {
+	UDP         string
+	IPv4        string
+	IPv6        string
+	NearestDERP string
+	DERP        []DERPLatency
+}
+
+// ParseNetcheck reads the "* Key: value" report of `tailscale netcheck`.
+// The text form is used because --format=json is missing on older releases.
+func ParseNetcheck(out string) Netcheck {
+	var nc Netcheck
+	inDERP := false
+	for _, line := range strings.Split(out, "\n") {
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "- ") && inDERP {
+			// "- sin: 12.3ms  (Singapore)"
+			region, rest, ok := strings.Cut(strings.TrimPrefix(t, "- "), ":")
+			if !ok {
+				continue
+			}
+			f := strings.Fields(rest)
+			d := DERPLatency{Region: region}
+			if len(f) > 0 {
+				d.Latency = f[0]
+			}
+			if len(f) > 1 {
+				d.Name = strings.Trim(strings.Join(f[1:], " "), "()")
+			}
+			nc.DERP = append(nc.DERP, d)
+			continue
+		}
+		key, val, ok := strings.Cut(strings.TrimPrefix(t, "* "), ":")
+		if !ok || !strings.HasPrefix(t, "* ") {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		inDERP = false
+		switch key {
+		case "UDP":
+			nc.UDP = val
+		case "IPv4":
+			nc.IPv4 = val
+		case "IPv6":
+			nc.IPv6 = val
+		case "Nearest DERP":
+			nc.NearestDERP = val
+		case "DERP latency":
+			inDERP = true
+		}
+	}
+	return nc
+}
+
+// tailscaleStatus runs `tailscale status --json`.
+func tailscaleStatus(ctx context.Context, ex *executor.Runner) (TailscaleStatus, error) {
+	res, err := ex.Run(ctx, cmdTimeout, "tailscale", "status", "--json")
+	// status exits non-zero when logged out but still prints JSON
+	if res.Stdout == "" && err != nil {
+		return TailscaleStatus{}, err
+	}
+	return ParseTailscaleStatus([]byte(res.Stdout))
+}
+
+// Tailscale reports backend, login/key state, self IPs, peers and netcheck results.
+func (m *MonitoringService) Tailscale(ctx context.Context) (string, error) {
+	st, err := tailscaleStatus(ctx, m.exec)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	lines := []string{fmt.Sprintf("tailscale %s: %s", strings.Split(st.Version, "-")[0], st.BackendState)}
+	self := st.Self.HostName
+	if dns := strings.TrimSuffix(st.Self.DNSName, "."); dns != "" {
+		self += " (" + dns + ")"
+	}
+	lines = append(lines, fmt.Sprintf("self: %s %s", self, strings.Join(st.Self.TailscaleIPs, ", ")))
+	if st.Self.KeyExpiry == nil {
+		lines = append(lines, "key expiry: disabled")
+	} else {
+		lines = append(lines, fmt.Sprintf("key expiry: %s (in %s)", st.Self.KeyExpiry.Format("2006-01-02"), st.Self.KeyExpiry.Sub(now).Round(time.Hour)))
+	}
+	for _, h := range st.Health {
+		lines = append(lines, "health: "+h)
+	}
+
+	online := 0
+	for _, p := range st.Peers {
+		if p.Online {
+			online++
+		}
+	}
+	lines = append(lines, fmt.Sprintf("peers: %d/%d online", online, len(st.Peers)))
+	shown := 0
+	for _, p := range st.Peers {
+		if !p.Online {
+			continue
+		}
+		if shown == maxTailscalePeers {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", online-shown))
+			break
+		}
+		shown++
+		path := "idle"
+		switch {
+		case p.CurAddr != "":
+			path = "direct " + p.CurAddr
+		case p.Relay != "":
+			path = "relay " + p.Relay
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s %s", p.HostName, firstIP(p.TailscaleIPs), path))
+	}
+
+	if res, err := m.exec.Run(ctx, netcheckTimeout, "tailscale", "netcheck"); err != nil && res.Stdout == "" {
+		lines = append(lines, "netcheck: "+err.Error())
+	} else {
+		nc := ParseNetcheck(res.Stdout)
+		lines = append(lines, fmt.Sprintf("netcheck: UDP %s, IPv4 %s, IPv6 %s, nearest DERP %s",
+			orDash(nc.UDP), orDash(nc.IPv4), orDash(nc.IPv6), orDash(nc.NearestDERP)))
+		for i, d := range nc.DERP {
+			if i == maxDERPRegions {
+				break
+			}
+			lines = append(lines, fmt.Sprintf("  %s %s %s", d.Region, d.Latency, d.Name))
+		}
+	}
+
+	if problems := st.Problems(now); len(problems) > 0 {
+		lines = append(lines, "PROBLEMS:")
+		for _, p := range problems {
+			lines = append(lines, "  "+p)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func firstIP(s []string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s[0]
+}
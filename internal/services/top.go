@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// procRoot is the procfs mount /top samples.
+var procRoot = "/proc"
+
+const (
+	topInterval = time.Second
+	// clkTck is USER_HZ, fixed at 100 on every Linux architecture DSM ships on.
+	clkTck        = 100
+	maxStuckNames = 5
+)
+
+// cgroupContainerRe finds a docker container id in a /proc/<pid>/cgroup path
+// (cgroup v2 "docker-<id>.scope" or v1 "/docker/<id>").
+var cgroupContainerRe = regexp.MustCompile(`docker[-/]([0-9a-f]{64})`)
+
+// ProcSample is one reading of /proc/<pid>.
+type ProcSample struct {
+	PID       int
+	PPID      int
+	Name      string
+	State     string
+	Ticks     uint64 // utime + stime
+	RSSKB     uint64
+	Container string // short container id, empty for host processes
+}
+
+// ParseProcStat reads ppid, state and CPU ticks from /proc/<pid>/stat.
+// comm may contain spaces and parentheses, so fields are split after the last ')'.
+func ParseProcStat(s string) (ppid int, state string, ticks uint64, err error) {
+	i := strings.LastIndexByte(s, ')')
+	if i < 0 {
+		return 0, "", 0, errors.New("malformed stat")
+	}
+	f := strings.Fields(s[i+1:])
+	// f[0] is field 3 (state); utime/stime are fields 14/15
+	if len(f) < 13 {
+		return 0, "", 0, errors.New("short stat")
+	}
+	ppid, _ = strconv.Atoi(f[1])
+	utime, _ := strconv.ParseUint(f[11], 10, 64)
+	stime, _ := strconv.ParseUint(f[12], 10, 64)
+	return ppid, f[0], utime + stime, nil
+}
+
+// ParseProcStatus reads Name and VmRSS (kB) from /proc/<pid>/status.
+func ParseProcStatus(s string) (name string, rssKB uint64) {
+	for _, line := range strings.Split(s, "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "Name":
+			name = strings.TrimSpace(v)
+		case "VmRSS":
+			f := strings.Fields(v)
+			if len(f) > 0 {
+				rssKB, _ = strconv.ParseUint(f[0], 10, 64)
+			}
+		}
+	}
+	return name, rssKB
+}
+
+// ContainerFromCgroup returns the short docker container id a process belongs to.
+func ContainerFromCgroup(s string) string {
+	if m := cgroupContainerRe.FindStringSubmatch(s); m != nil {
+		return shortID(m[1])
+	}
+	return ""
+}
+
+// ReadProcSamples reads every numeric /proc entry under root. Processes that
+// exit mid-scan are skipped.
+func ReadProcSamples(root string) (map[int]ProcSample, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int]ProcSample, len(entries))
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+		ppid, state, ticks, err := ParseProcStat(string(stat))
+		if err != nil {
+			continue
+		}
+		p := ProcSample{PID: pid, PPID: ppid, State: state, Ticks: ticks}
+		if status, err := os.ReadFile(filepath.Join(dir, "status")); err == nil {
+			p.Name, p.RSSKB = ParseProcStatus(string(status))
+		}
+		if cg, err := os.ReadFile(filepath.Join(dir, "cgroup")); err == nil {
+			p.Container = ContainerFromCgroup(string(cg))
+		}
+		out[pid] = p
+	}
+	return out, nil
+}
+
+// ProcStat is a process with its CPU usage over the sample interval.
+type ProcStat struct {
+	ProcSample
+	CPU float64 // percent of one core
+}
+
+// ProcTable is the /top view of the process list.
+type ProcTable struct {
+	Procs   []ProcStat
+	Zombies []ProcStat
+	DState  []ProcStat
+}
+
+// BuildProcTable derives CPU % from two samples taken elapsed apart.
+// Processes that started in between are measured from zero.
+func BuildProcTable(before, after map[int]ProcSample, elapsed time.Duration) ProcTable {
+	var t ProcTable
+	secs := elapsed.Seconds()
+	for pid, p := range after {
+		st := ProcStat{ProcSample: p}
+		prev, ok := before[pid]
+		if !ok || prev.Ticks > p.Ticks {
+			prev.Ticks = 0
+		}
+		if secs > 0 {
+			st.CPU = float64(p.Ticks-prev.Ticks) / clkTck / secs * 100
+		}
+		t.Procs = append(t.Procs, st)
+		switch p.State {
+		case "Z":
+			t.Zombies = append(t.Zombies, st)
+		case "D":
+			t.DState = append(t.DState, st)
+		}
+	}
+	sort.Slice(t.Procs, func(i, j int) bool { return t.Procs[i].PID < t.Procs[j].PID })
+	return t
+}
+
+// Sorted returns the top n processes by cpu or mem (RSS), descending.
+func (t ProcTable) Sorted(by string, n int) ([]ProcStat, error) {
+	var less func(a, b ProcStat) bool
+	switch by {
+	case "cpu":
+		less = func(a, b ProcStat) bool { return a.CPU > b.CPU || (a.CPU == b.CPU && a.RSSKB > b.RSSKB) }
+	case "mem":
+		less = func(a, b ProcStat) bool { return a.RSSKB > b.RSSKB }
+	default:
+		return nil, fmt.Errorf("unknown sort key %q (cpu|mem)", by)
+	}
+	out := append([]ProcStat(nil), t.Procs...)
+	sort.SliceStable(out, func(i, j int) bool { return less(out[i], out[j]) })
+	if n > 0 && len(out) > n {
+		out = out[:n]
+	}
+	return out, nil
+}
+
+// Render formats the top n rows plus zombie and D-state summaries. names
+// maps short container ids to names; unknown ids are shown as-is.
+func (t ProcTable) Render(by string, n int, names map[string]string) (string, error) {
+	rows, err := t.Sorted(by, n)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{fmt.Sprintf("%d processes, %d zombie, %d D-state (uninterruptible I/O); top %d by %s",
+		len(t.Procs), len(t.Zombies), len(t.DState), len(rows), by)}
+	for _, p := range rows {
+		lines = append(lines, fmt.Sprintf("%d %s %s cpu=%.1f%% rss=%s%s", p.PID, p.Name, p.State, p.CPU,
+			humanBytes(p.RSSKB*1024), containerLabel(p.Container, names)))
+	}
+	if len(t.DState) > 0 {
+		lines = append(lines, "D-state: "+procList(t.DState, names))
+	}
+	if len(t.Zombies) > 0 {
+		parents := map[int]int{}
+		for _, z := range t.Zombies {
+			parents[z.PPID]++
+		}
+		var desc []string
+		for ppid, cnt := range parents {
+			desc = append(desc, fmt.Sprintf("%d under ppid %d", cnt, ppid))
+		}
+		sort.Strings(desc)
+		lines = append(lines, "zombies: "+strings.Join(desc, ", "))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func containerLabel(id string, names map[string]string) string {
+	if id == "" {
+		return ""
+	}
+	if n, ok := names[id]; ok {
+		return " [" + n + "]"
+	}
+	return " [" + id + "]"
+}
+
+func procList(ps []ProcStat, names map[string]string) string {
+	sort.Slice(ps, func(i, j int) bool { return ps[i].PID < ps[j].PID })
+	var out []string
+	for i, p := range ps {
+		if i == maxStuckNames {
+			out = append(out, fmt.Sprintf("and %d more", len(ps)-i))
+			break
+		}
+		out = append(out, fmt.Sprintf("%s(%d)%s", p.Name, p.PID, containerLabel(p.Container, names)))
+	}
+	return strings.Join(out, ", ")
+}
+
+// Top samples /proc twice and renders the top n processes by cpu or mem.
+func (m *MonitoringService) Top(ctx context.Context, by string, n int) (string, error) {
+	if _, err := (ProcTable{}).Sorted(by, n); err != nil {
+		return "", err
+	}
+	before, err := ReadProcSamples(procRoot)
+	if err != nil {
+		return "", err
+	}
+	start := time.Now()
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(topInterval):
+	}
+	after, err := ReadProcSamples(procRoot)
+	if err != nil {
+		return "", err
+	}
+	t := BuildProcTable(before, after, time.Since(start))
+	return t.Render(by, n, m.containerNames(ctx))
+}
+
+// containerNames maps short ids of running containers to names; best effort.
+func (m *MonitoringService) containerNames(ctx context.Context) map[string]string {
+	names := map[string]string{}
+	list, err := m.docker.api.List(ctx, false)
+	if err != nil {
+		return names
+	}
+	for _, c := range list {
+		names[shortID(c.ID)] = c.Name()
+	}
+	return names
+}
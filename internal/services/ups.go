@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"zckyachmd/lifeline/pkg/nut"
+)
+
+// UPSStatus is a normalized view of UPS state from DSM or NUT.
+type UPSStatus struct {
+	Source     string
+	Model      string
+	Status     string
+	Charge     float64
+	RuntimeSec int
+	Load       float64
+	OnBattery  bool
+	LowBattery bool
+}
+
+// ParseNUTVars maps NUT variables (battery.charge, ups.status, ...) to UPSStatus.
+func ParseNUTVars(vars map[string]string) UPSStatus {
+	st := UPSStatus{
+		Source: "nut",
+		Model:  strings.TrimSpace(vars["ups.mfr"] + " " + vars["ups.model"]),
+		Status: vars["ups.status"],
+	}
+	st.Charge, _ = strconv.ParseFloat(vars["battery.charge"], 64)
+	st.Load, _ = strconv.ParseFloat(vars["ups.load"], 64)
+	if rt, err := strconv.ParseFloat(vars["battery.runtime"], 64); err == nil {
+		st.RuntimeSec = int(rt)
+	}
+	for _, flag := range strings.Fields(st.Status) {
+		switch flag {
+		case "OB":
+			st.OnBattery = true
+		case "LB":
+			st.LowBattery = true
+		}
+	}
+	return st
+}
+
+// ParseDSMUPS maps a SYNO.Core.ExternalDevice.UPS get payload to UPSStatus.
+func ParseDSMUPS(data map[string]any) UPSStatus {
+	status := strings.TrimPrefix(asString(data["status"]), "usb_ups_status_")
+	st := UPSStatus{
+		Source:     "dsm",
+		Model:      strings.TrimSpace(asString(data["manufacture"]) + " " + asString(data["model"])),
+		Status:     status,
+		Charge:     asFloat(data["charge"]),
+		RuntimeSec: int(asFloat(data["runtime"])),
+		Load:       asFloat(data["load"]),
+	}
+	switch status {
+	case "onbattery", "battery":
+		st.OnBattery = true
+	case "lowbattery", "low_battery":
+		st.OnBattery = true
+		st.LowBattery = true
+	}
+	return st
+}
+
+// UPS reads UPS state from the configured source.
+func (m *MonitoringService) UPS(ctx context.Context) (UPSStatus, error) {
+	switch m.ups.Source {
+	case "nut":
+		client := nut.New(m.ups.NUTAddr, m.ups.NUTUser, m.ups.NUTPassword, 5*time.Second)
+		vars, err := client.Vars(ctx, m.ups.NUTName)
+		if err != nil {
+			return UPSStatus{}, err
+		}
+		return ParseNUTVars(vars), nil
+	case "dsm":
+		resp, err := m.dsm.UPSInfo(ctx)
+		if err != nil {
+			return UPSStatus{}, err
+		}
+		data, err := dsmData(resp)
+		if err != nil {
+			return UPSStatus{}, err
+		}
+		if enabled, ok := data["enable"].(bool); ok && !enabled {
+			return UPSStatus{}, fmt.Errorf("ups support disabled in DSM")
+		}
+		return ParseDSMUPS(data), nil
+	default:
+		return UPSStatus{}, fmt.Errorf("ups not configured")
+	}
+}
+
+// UPSReport renders battery charge, runtime, load and power source.
+func (m *MonitoringService) UPSReport(ctx context.Context) (string, error) {
+	st, err := m.UPS(ctx)
+	if err != nil {
+		return "", err
+	}
+	power := "mains"
+	if st.OnBattery {
+		power = "ON BATTERY"
+	}
+	if st.LowBattery {
+		power += " (LOW BATTERY)"
+	}
+	lines := []string{
+		fmt.Sprintf("UPS (%s) %s", st.Source, st.Model),
+		fmt.Sprintf("power: %s", power),
+		fmt.Sprintf("status: %s", st.Status),
+		fmt.Sprintf("charge: %.0f%%", st.Charge),
+		fmt.Sprintf("runtime: %s", (time.Duration(st.RuntimeSec) * time.Second).String()),
+		fmt.Sprintf("load: %.0f%%", st.Load),
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// UPSOnBatteryCheck is an alert check that fires while running on battery.
+func (m *MonitoringService) UPSOnBatteryCheck(ctx context.Context) (bool, string, error) {
+	st, err := m.UPS(ctx)
+	if err != nil {
+		return false, "", err
+	}
+	if st.OnBattery {
+		return true, fmt.Sprintf("UPS on battery, charge %.0f%%, runtime %s", st.Charge, time.Duration(st.RuntimeSec)*time.Second), nil
+	}
+	return false, fmt.Sprintf("UPS back on mains, charge %.0f%%", st.Charge), nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// VerifyResult is the outcome of a post-restart verification.
+type VerifyResult struct {
+	Healthy bool
+	Elapsed time.Duration
+	Detail  string
+	Logs    string // last log lines, only when unhealthy
+}
+
+// CanVerify reports whether a restarted service has a health check.
+// Compose stacks report per-container state from the restart itself.
+func (s *SystemService) CanVerify(service string) bool {
+	return allowedService(service) || s.isAllowedContainer(service)
+}
+
+// VerifyTimeout bounds how long a restarted service is polled.
+func (s *SystemService) VerifyTimeout() time.Duration {
+	return time.Duration(s.verify.TimeoutSeconds) * time.Second
+}
+
+// VerifyRestart polls status (and the optional probe) of a restarted service
+// until it is healthy or the verify timeout passes. progress receives the
+// current state after every poll.
+func (s *SystemService) VerifyRestart(ctx context.Context, service string, progress func(detail string, elapsed time.Duration)) VerifyResult {
+	timeout := s.VerifyTimeout()
+	interval := time.Duration(s.verify.IntervalSeconds) * time.Second
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var res VerifyResult
+	for {
+		res.Healthy, res.Detail = s.ServiceHealth(ctx, service)
+		res.Elapsed = time.Since(start).Round(time.Second)
+		if res.Healthy {
+			return res
+		}
+		if progress != nil {
+			progress(res.Detail, res.Elapsed)
+		}
+		select {
+		case <-ctx.Done():
+			logCtx, logCancel := context.WithTimeout(context.Background(), cmdTimeout)
+			defer logCancel()
+			res.Logs, _ = s.lastLogs(logCtx, service, 20)
+			return res
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ServiceHealth checks the service state and, when available, its health probe.
+func (s *SystemService) ServiceHealth(ctx context.Context, service string) (bool, string) {
+	switch strings.ToLower(service) {
+	case "cloudflared":
+		ok, st := s.containerHealthy(ctx, "cloudflared")
+		if !ok || s.cloudflared.MetricsAddr == "" {
+			return ok, st
+		}
+		ready, detail := s.cloudflaredReady(ctx)
+		return ready, st + ", " + detail
+	case "tailscale", "tailscaled":
+		ok, st := s.unitActive(ctx, "tailscaled.service")
+		if !ok || !s.exec.Available("tailscale") {
+			return ok, st
+		}
+		ts, err := tailscaleStatus(ctx, s.exec)
+		if err != nil {
+			return false, fmt.Sprintf("%s, tailscale status: %v", st, err)
+		}
+		return ts.BackendState == "Running", st + ", backend " + ts.BackendState
+	case "docker":
+		ok, st := s.unitActive(ctx, "docker.service")
+		if !ok {
+			return ok, st
+		}
+		if err := s.docker.api.Ping(ctx); err != nil {
+			return false, st + ", api: " + err.Error()
+		}
+		return true, st + ", api ok"
+	default:
+		return s.containerHealthy(ctx, service)
+	}
+}
+
+func (s *SystemService) containerHealthy(ctx context.Context, name string) (bool, string) {
+	st, err := s.docker.Status(ctx, name)
+	if err != nil {
+		return false, err.Error()
+	}
+	healthy := strings.HasPrefix(st, "running") && !strings.Contains(st, "(starting)") && !strings.Contains(st, "(unhealthy)")
+	return healthy, st
+}
+
+func (s *SystemService) unitActive(ctx context.Context, unit string) (bool, string) {
+	out, err := runCmd(ctx, s.exec, "systemctl", "is-active", unit)
+	st := strings.TrimSpace(out)
+	if st == "" && err != nil {
+		st = err.Error()
+	}
+	return st == "active", st
+}
+
+// cloudflaredReady queries cloudflared's /ready endpoint on the metrics server.
+func (s *SystemService) cloudflaredReady(ctx context.Context) (bool, string) {
+	ready, err := fetchCloudflaredReady(ctx, s.http, s.cloudflared.MetricsAddr)
+	if err != nil {
+		return false, err.Error()
+	}
+	return ready.OK(), fmt.Sprintf("ready %d, %d connections", ready.Status, ready.ReadyConnections)
+}
+
+// lastLogs tails a built-in service or an allowlisted container.
+func (s *SystemService) lastLogs(ctx context.Context, service string, lines int) (string, error) {
+	if allowedService(service) {
+		return s.TailLogs(ctx, service, lines)
+	}
+	return s.docker.Logs(ctx, service, lines)
+}
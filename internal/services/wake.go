@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+const (
+	wakeRepeats   = 3
+	wakePollEvery = 5 * time.Second
+	reachTimeout  = 2 * time.Second
+)
+
+// MagicPacket builds the Wake-on-LAN payload: six 0xFF bytes followed by
+// the MAC address repeated 16 times.
+func MagicPacket(mac net.HardwareAddr) []byte {
+	pkt := make([]byte, 0, 6+16*len(mac))
+	for i := 0; i < 6; i++ {
+		pkt = append(pkt, 0xFF)
+	}
+	for i := 0; i < 16; i++ {
+		pkt = append(pkt, mac...)
+	}
+	return pkt
+}
+
+// WakeAliases lists the configured /wake targets.
+func (s *SystemService) WakeAliases() []string {
+	out := make([]string, 0, len(s.wake.Hosts))
+	for alias := range s.wake.Hosts {
+		out = append(out, alias)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// IsWakeAlias reports whether alias is in the wake allowlist.
+func (s *SystemService) IsWakeAlias(alias string) bool {
+	_, ok := s.wake.Hosts[alias]
+	return ok
+}
+
+// WakeCheck returns the reachability target polled after waking alias, if any.
+func (s *SystemService) WakeCheck(alias string) string {
+	return s.wake.Hosts[alias].Check
+}
+
+// WakePoll bounds how long a woken host is polled.
+func (s *SystemService) WakePoll() time.Duration {
+	return time.Duration(s.wake.PollSeconds) * time.Second
+}
+
+// Wake sends the magic packet for an allowlisted alias a few times, since
+// UDP broadcasts are easily dropped by sleeping NICs and busy switches.
+func (s *SystemService) Wake(ctx context.Context, alias string) (string, error) {
+	h, ok := s.wake.Hosts[alias]
+	if !ok {
+		return "", fmt.Errorf("unknown wake host %s", alias)
+	}
+	mac, err := net.ParseMAC(h.MAC)
+	if err != nil {
+		return "", err
+	}
+	local, bcast, err := wakeAddrs(h.Interface, h.Broadcast)
+	if err != nil {
+		return "", err
+	}
+	dst := &net.UDPAddr{IP: bcast, Port: h.Port}
+	conn, err := net.DialUDP("udp4", local, dst)
+	if err != nil {
+		return "", fmt.Errorf("wake %s: %w", alias, err)
+	}
+	defer conn.Close()
+	pkt := MagicPacket(mac)
+	for i := 0; i < wakeRepeats; i++ {
+		if _, err := conn.Write(pkt); err != nil {
+			return "", fmt.Errorf("wake %s: %w", alias, err)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	via := ""
+	if h.Interface != "" {
+		via = " via " + h.Interface
+	}
+	return fmt.Sprintf("Magic packet sent to %s (%s) on %s%s", alias, mac, dst, via), nil
+}
+
+// wakeAddrs resolves the local bind address and broadcast target. With an
+// interface, packets leave from its first IPv4 address and the broadcast
+// defaults to that subnet's.
+func wakeAddrs(iface, broadcast string) (*net.UDPAddr, net.IP, error) {
+	bcast := net.IPv4bcast
+	if broadcast != "" {
+		bcast = net.ParseIP(broadcast).To4()
+	}
+	if iface == "" {
+		return nil, bcast, nil
+	}
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, nil, fmt.Errorf("interface %s: %w", iface, err)
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, nil, fmt.Errorf("interface %s: %w", iface, err)
+	}
+	for _, a := range addrs {
+		ipn, ok := a.(*net.IPNet)
+		if !ok || ipn.IP.To4() == nil {
+			continue
+		}
+		ip, mask := ipn.IP.To4(), ipn.Mask
+		if len(mask) == net.IPv6len {
+			mask = mask[12:]
+		}
+		if broadcast == "" {
+			bcast = make(net.IP, 4)
+			for i := range ip {
+				bcast[i] = ip[i] | ^mask[i]
+			}
+		}
+		return &net.UDPAddr{IP: ip}, bcast, nil
+	}
+	return nil, nil, fmt.Errorf("interface %s has no IPv4 address", iface)
+}
+
+// WaitReachable polls a woken host's check target until it answers or the
+// wake poll window passes. progress receives the elapsed time after each
+// failed attempt.
+func (s *SystemService) WaitReachable(ctx context.Context, alias string, progress func(elapsed time.Duration)) (bool, time.Duration, error) {
+	target := s.WakeCheck(alias)
+	if target == "" {
+		return false, 0, errors.New("no check configured for " + alias)
+	}
+	pollCtx, cancel := context.WithTimeout(ctx, s.WakePoll())
+	defer cancel()
+	start := time.Now()
+	for {
+		if s.reachable(pollCtx, target) {
+			return true, time.Since(start).Round(time.Second), nil
+		}
+		elapsed := time.Since(start).Round(time.Second)
+		if progress != nil {
+			progress(elapsed)
+		}
+		select {
+		case <-pollCtx.Done():
+			// the poll window running out is an answer, not an error
+			return false, elapsed, ctx.Err()
+		case <-time.After(wakePollEvery):
+		}
+	}
+}
+
+// reachable tries a TCP connect for host:port targets and a single ping otherwise.
+func (s *SystemService) reachable(ctx context.Context, target string) bool {
+	if strings.Contains(target, ":") {
+		return tcpReachable(ctx, target, reachTimeout) == nil
+	}
+	return pingReachable(ctx, s.exec, target, reachTimeout) == nil
+}
+
+func tcpReachable(ctx context.Context, addr string, timeout time.Duration) error {
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func pingReachable(ctx context.Context, ex *executor.Runner, host string, timeout time.Duration) error {
+	secs := strconv.Itoa(max(int(timeout.Seconds()), 1))
+	_, err := ex.Run(ctx, timeout+time.Second, "ping", "-c", "1", "-W", secs, host)
+	return err
+}
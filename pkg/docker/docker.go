@@ -0,0 +1,468 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrNotFound is returned when the daemon reports 404 for a container.
+var ErrNotFound = errors.New("docker: no such container")
+
+// maxLogBytes caps how much log output is read from the daemon.
+const maxLogBytes = 2 * 1024 * 1024
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,127}$`)
+
+// Client is a minimal Docker Engine API client over the unix socket.
+type Client struct {
+	http    *http.Client
+	timeout time.Duration
+}
+
+// New creates a client for the given unix socket with a per-request timeout.
+func New(socket string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: 2 * time.Second}
+			return d.DialContext(ctx, "unix", socket)
+		},
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          2,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	return &Client{http: &http.Client{Transport: transport}, timeout: timeout}
+}
+
+// Unreachable reports whether err means the socket could not be dialled
+// (daemon down, socket missing or not permitted). Timeouts and API errors
+// from a running daemon are not unreachable.
+func Unreachable(err error) bool {
+	var op *net.OpError
+	if !errors.As(err, &op) || op.Op != "dial" || op.Timeout() {
+		return false
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.EACCES)
+}
+
+// ValidName reports whether name is a plain container name or id.
+func ValidName(name string) bool {
+	return nameRe.MatchString(name)
+}
+
+// State mirrors the subset of container state the bot reports.
+type State struct {
+	Status     string `json:"Status"`
+	Running    bool   `json:"Running"`
+	Restarting bool   `json:"Restarting"`
+	ExitCode   int    `json:"ExitCode"`
+	StartedAt  string `json:"StartedAt"`
+	FinishedAt string `json:"FinishedAt"`
+	Health     *struct {
+		Status        string `json:"Status"`
+		FailingStreak int    `json:"FailingStreak"`
+	} `json:"Health"`
+}
+
+// Container is the inspect result subset.
+type Container struct {
+	ID           string `json:"Id"`
+	Name         string `json:"Name"`
+	RestartCount int    `json:"RestartCount"`
+	State        State  `json:"State"`
+	Config       struct {
+		Image  string            `json:"Image"`
+		Tty    bool              `json:"Tty"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	LogPath string `json:"LogPath"`
+}
+
+// HealthStatus returns the healthcheck status or "" when none is defined.
+func (c Container) HealthStatus() string {
+	if c.State.Health == nil {
+		return ""
+	}
+	return c.State.Health.Status
+}
+
+// Ping checks the daemon answers.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, "/_ping", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Inspect returns container details.
+func (c *Client) Inspect(ctx context.Context, name string) (Container, error) {
+	var out Container
+	if !ValidName(name) {
+		return out, fmt.Errorf("invalid container name")
+	}
+	err := c.getJSON(ctx, "/containers/"+url.PathEscape(name)+"/json", nil, &out)
+	return out, err
+}
+
+// Summary is an entry of the container list.
+type Summary struct {
+	ID     string   `json:"Id"`
+	Names  []string `json:"Names"`
+	Image  string   `json:"Image"`
+	State  string   `json:"State"`
+	Status string   `json:"Status"`
+}
+
+// Name returns the primary container name without the leading slash.
+func (s Summary) Name() string {
+	if len(s.Names) == 0 {
+		return s.ID
+	}
+	return strings.TrimPrefix(s.Names[0], "/")
+}
+
+// List returns containers; all includes stopped ones. Optional labels
+// ("key=value") restrict the result, e.g. to a compose project.
+func (c *Client) List(ctx context.Context, all bool, labels ...string) ([]Summary, error) {
+	var out []Summary
+	q := url.Values{}
+	if all {
+		q.Set("all", "1")
+	}
+	if len(labels) > 0 {
+		filters, err := json.Marshal(map[string][]string{"label": labels})
+		if err != nil {
+			return nil, err
+		}
+		q.Set("filters", string(filters))
+	}
+	err := c.getJSON(ctx, "/containers/json", q, &out)
+	return out, err
+}
+
+// Restart restarts a container, giving it waitSec seconds to stop.
+func (c *Client) Restart(ctx context.Context, name string, waitSec int) error {
+	if !ValidName(name) {
+		return fmt.Errorf("invalid container name")
+	}
+	q := url.Values{"t": {strconv.Itoa(waitSec)}}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout+time.Duration(waitSec)*time.Second)
+	defer cancel()
+	resp, err := c.doNoTimeout(ctx, http.MethodPost, "/containers/"+url.PathEscape(name)+"/restart", q)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// LogOptions selects which log lines to fetch.
+type LogOptions struct {
+	Tail       int
+	Since      time.Time
+	Timestamps bool
+}
+
+// Logs returns container logs with stdout/stderr frames demultiplexed in order.
+func (c *Client) Logs(ctx context.Context, name string, opts LogOptions) (string, error) {
+	info, err := c.Inspect(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+	if opts.Tail > 0 {
+		q.Set("tail", strconv.Itoa(opts.Tail))
+	}
+	if !opts.Since.IsZero() {
+		q.Set("since", strconv.FormatInt(opts.Since.Unix(), 10))
+	}
+	if opts.Timestamps {
+		q.Set("timestamps", "1")
+	}
+	resp, err := c.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(name)+"/logs", q)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body := io.LimitReader(resp.Body, maxLogBytes)
+	var buf bytes.Buffer
+	if info.Config.Tty {
+		_, err = io.Copy(&buf, body)
+	} else {
+		err = Demux(body, &buf, &buf)
+	}
+	return buf.String(), err
+}
+
+// FollowLogs streams new log output of a container into w until ctx is done.
+// Only lines written after the call are delivered.
+func (c *Client) FollowLogs(ctx context.Context, name string, w io.Writer) error {
+	info, err := c.Inspect(ctx, name)
+	if err != nil {
+		return err
+	}
+	q := url.Values{"stdout": {"1"}, "stderr": {"1"}, "follow": {"1"}, "tail": {"0"}}
+	resp, err := c.doNoTimeout(ctx, http.MethodGet, "/containers/"+url.PathEscape(name)+"/logs", q)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if info.Config.Tty {
+		_, err = io.Copy(w, resp.Body)
+	} else {
+		err = Demux(resp.Body, w, w)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// Demux splits a multiplexed attach/logs stream into stdout and stderr.
+func Demux(r io.Reader, stdout, stderr io.Writer) error {
+	hdr := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[4:]))
+		var w io.Writer
+		switch hdr[0] {
+		case 1:
+			w = stdout
+		case 2:
+			w = stderr
+		default:
+			w = io.Discard
+		}
+		if _, err := io.CopyN(w, r, size); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// CPUStats is a cpu_stats/precpu_stats block.
+type CPUStats struct {
+	CPUUsage struct {
+		TotalUsage  uint64   `json:"total_usage"`
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	SystemUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs  uint32 `json:"online_cpus"`
+}
+
+// Stats is a single stats sample.
+type Stats struct {
+	Name        string   `json:"name"`
+	ID          string   `json:"id"`
+	CPUStats    CPUStats `json:"cpu_stats"`
+	PreCPUStats CPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+	BlkioStats struct {
+		IOServiceBytesRecursive []struct {
+			Op    string `json:"op"`
+			Value uint64 `json:"value"`
+		} `json:"io_service_bytes_recursive"`
+	} `json:"blkio_stats"`
+}
+
+// Stats takes one non-streaming sample; the daemon includes the previous
+// CPU reading so a percentage can be derived from a single call.
+func (c *Client) Stats(ctx context.Context, name string) (Stats, error) {
+	var out Stats
+	if !ValidName(name) {
+		return out, fmt.Errorf("invalid container name")
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout+3*time.Second)
+	defer cancel()
+	err := c.getJSONNoTimeout(ctx, "/containers/"+url.PathEscape(name)+"/stats", url.Values{"stream": {"false"}}, &out)
+	return out, err
+}
+
+// DiskUsage is the /system/df summary.
+type DiskUsage struct {
+	LayersSize int64 `json:"LayersSize"`
+	Images     []struct {
+		ID         string   `json:"Id"`
+		RepoTags   []string `json:"RepoTags"`
+		Size       int64    `json:"Size"`
+		SharedSize int64    `json:"SharedSize"`
+		Containers int      `json:"Containers"`
+	} `json:"Images"`
+	Containers []struct {
+		ID     string   `json:"Id"`
+		Names  []string `json:"Names"`
+		SizeRw int64    `json:"SizeRw"`
+		State  string   `json:"State"`
+	} `json:"Containers"`
+	Volumes []struct {
+		Name      string `json:"Name"`
+		UsageData struct {
+			Size     int64 `json:"Size"`
+			RefCount int   `json:"RefCount"`
+		} `json:"UsageData"`
+	} `json:"Volumes"`
+	BuildCache []struct {
+		ID     string `json:"ID"`
+		Size   int64  `json:"Size"`
+		InUse  bool   `json:"InUse"`
+		Shared bool   `json:"Shared"`
+	} `json:"BuildCache"`
+}
+
+// DiskUsage returns image/container/volume/build cache usage.
+func (c *Client) DiskUsage(ctx context.Context) (DiskUsage, error) {
+	var out DiskUsage
+	ctx, cancel := context.WithTimeout(ctx, 3*c.timeout)
+	defer cancel()
+	err := c.getJSONNoTimeout(ctx, "/system/df", nil, &out)
+	return out, err
+}
+
+// PruneReport is the result of a prune call.
+type PruneReport struct {
+	Deleted        []string
+	SpaceReclaimed uint64
+}
+
+// Prune removes unused objects of a kind: containers, images, build or networks.
+// Images are limited to dangling ones.
+func (c *Client) Prune(ctx context.Context, kind string) (PruneReport, error) {
+	var q url.Values
+	switch kind {
+	case "containers", "build", "networks":
+	case "images":
+		q = url.Values{"filters": {`{"dangling":["true"]}`}}
+	default:
+		return PruneReport{}, fmt.Errorf("unknown prune kind %q", kind)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*c.timeout)
+	defer cancel()
+	resp, err := c.doNoTimeout(ctx, http.MethodPost, "/"+kind+"/prune", q)
+	if err != nil {
+		return PruneReport{}, err
+	}
+	defer resp.Body.Close()
+	var raw struct {
+		ContainersDeleted []string `json:"ContainersDeleted"`
+		ImagesDeleted     []struct {
+			Untagged string `json:"Untagged"`
+			Deleted  string `json:"Deleted"`
+		} `json:"ImagesDeleted"`
+		CachesDeleted   []string `json:"CachesDeleted"`
+		NetworksDeleted []string `json:"NetworksDeleted"`
+		SpaceReclaimed  uint64   `json:"SpaceReclaimed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return PruneReport{}, err
+	}
+	rep := PruneReport{SpaceReclaimed: raw.SpaceReclaimed}
+	rep.Deleted = append(rep.Deleted, raw.ContainersDeleted...)
+	rep.Deleted = append(rep.Deleted, raw.CachesDeleted...)
+	rep.Deleted = append(rep.Deleted, raw.NetworksDeleted...)
+	for _, img := range raw.ImagesDeleted {
+		if img.Deleted != "" {
+			rep.Deleted = append(rep.Deleted, img.Deleted)
+		} else if img.Untagged != "" {
+			rep.Deleted = append(rep.Deleted, img.Untagged)
+		}
+	}
+	return rep, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, p string, q url.Values, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.getJSONNoTimeout(ctx, p, q, out)
+}
+
+func (c *Client) getJSONNoTimeout(ctx context.Context, p string, q url.Values, out any) error {
+	resp, err := c.doNoTimeout(ctx, http.MethodGet, p, q)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do issues a request bounded by the client timeout. The timeout covers
+// reading the body too, so the caller must finish with it before returning.
+func (c *Client) do(ctx context.Context, method, p string, q url.Values) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	resp, err := c.doNoTimeout(ctx, method, p, q)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (c *Client) doNoTimeout(ctx context.Context, method, p string, q url.Values) (*http.Response, error) {
+	endpoint := "http://docker" + p
+	if len(q) > 0 {
+		endpoint += "?" + q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker api: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound && strings.HasPrefix(p, "/containers/") {
+			return nil, ErrNotFound
+		}
+		var msg struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&msg)
+		return nil, fmt.Errorf("docker api status %d: %s", resp.StatusCode, msg.Message)
+	}
+	return resp, nil
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,235 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrUnavailable is returned for binaries that are not configured or were missing at startup.
+var ErrUnavailable = errors.New("binary not available")
+
+// baseEnv is the whole environment handed to child processes.
+var baseEnv = []string{
+	"PATH=/usr/sbin:/usr/bin:/sbin:/bin",
+	"LC_ALL=C",
+	"SYSTEMD_PAGER=",
+}
+
+// waitDelay bounds how long Wait blocks on pipes held open by orphans after a kill.
+const waitDelay = 2 * time.Second
+
+// Result is the outcome of a finished command.
+type Result struct {
+	Stdout    string
+	Stderr    string
+	ExitCode  int
+	Duration  time.Duration
+	Truncated bool
+}
+
+// Output returns stdout followed by stderr.
+func (r Result) Output() string {
+	if r.Stderr == "" {
+		return r.Stdout
+	}
+	if r.Stdout == "" {
+		return r.Stderr
+	}
+	return r.Stdout + "\n" + r.Stderr
+}
+
+// Runner executes allowlisted binaries by absolute path.
+type Runner struct {
+	bins      map[string]string
+	missing   []string
+	maxOutput int
+}
+
+// New validates every configured binary: the path must be absolute, a regular
+// executable owned by root (or the current user) and not writable by group or
+// others, inside a directory that is not group/world writable either.
+// Binaries that do not exist are recorded as missing and refused at run time;
+// any other problem is fatal.
+func New(bins map[string]string, maxOutput int) (*Runner, error) {
+	if maxOutput <= 0 {
+		return nil, errors.New("max output must be >0")
+	}
+	r := &Runner{bins: make(map[string]string, len(bins)), maxOutput: maxOutput}
+	for name, path := range bins {
+		if path == "" {
+			continue
+		}
+		err := checkBinary(path)
+		if errors.Is(err, os.ErrNotExist) {
+			r.missing = append(r.missing, name)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("binary %s: %w", name, err)
+		}
+		r.bins[name] = path
+	}
+	return r, nil
+}
+
+func checkBinary(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("%s: path must be absolute", path)
+	}
+	// resolve symlinks (e.g. /bin -> /usr/bin) so the real file is checked
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s: not an executable file", real)
+	}
+	if err := checkOwner(real, info); err != nil {
+		return err
+	}
+	// every ancestor matters: a writable directory anywhere up the chain lets
+	// its owner rename a parent and swap the binary
+	for dir := filepath.Dir(real); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+		if err := checkOwner(dir, info); err != nil {
+			return err
+		}
+		if dir == filepath.Dir(dir) {
+			return nil
+		}
+	}
+}
+
+// checkOwner rejects files writable by group or others and files owned by
+// anyone but root or us. Sticky directories (e.g. /tmp) are exempt from the
+// write check, since others cannot replace entries they do not own; the
+// sticky bit means nothing on a regular file.
+func checkOwner(path string, info os.FileInfo) error {
+	sticky := info.IsDir() && info.Mode()&os.ModeSticky != 0
+	if info.Mode().Perm()&0o022 != 0 && !sticky {
+		return fmt.Errorf("%s: writable by group or others", path)
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if st.Uid != 0 && int(st.Uid) != os.Geteuid() {
+		return fmt.Errorf("%s: owned by uid %d", path, st.Uid)
+	}
+	return nil
+}
+
+// Missing lists configured binaries that were not found at startup.
+func (r *Runner) Missing() []string {
+	return r.missing
+}
+
+// Available reports whether name can be run.
+func (r *Runner) Available(name string) bool {
+	_, ok := r.bins[name]
+	return ok
+}
+
+// Run executes name with args, killing its whole process group when ctx or
+// timeout expires. A non-zero exit is returned as an error alongside the result.
+func (r *Runner) Run(ctx context.Context, timeout time.Duration, name string, args ...string) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stdout := &capWriter{max: r.maxOutput}
+	stderr := &capWriter{max: r.maxOutput}
+	cmd, err := r.command(ctx, name, args...)
+	if err != nil {
+		return Result{ExitCode: -1}, err
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	res := Result{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		ExitCode:  cmd.ProcessState.ExitCode(),
+		Duration:  time.Since(start),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+	switch {
+	case ctx.Err() != nil:
+		return res, fmt.Errorf("%s: %w after %s", name, ctx.Err(), res.Duration.Round(time.Millisecond))
+	case err != nil && res.ExitCode > 0:
+		return res, fmt.Errorf("%s exited with code %d", name, res.ExitCode)
+	case err != nil:
+		return res, fmt.Errorf("%s: %w", name, err)
+	}
+	return res, nil
+}
+
+// Stream runs a long-lived command (e.g. a log follower) writing stdout and
+// stderr to w until it exits or ctx is done.
+func (r *Runner) Stream(ctx context.Context, w io.Writer, name string, args ...string) error {
+	cmd, err := r.command(ctx, name, args...)
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
+
+func (r *Runner) command(ctx context.Context, name string, args ...string) (*exec.Cmd, error) {
+	path, ok := r.bins[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrUnavailable)
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = baseEnv
+	cmd.Dir = "/"
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// negative pid signals the whole group, including grandchildren
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = waitDelay
+	return cmd, nil
+}
+
+// capWriter keeps at most max bytes and discards the rest.
+type capWriter struct {
+	mu        sync.Mutex
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (w *capWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if room := w.max - len(w.buf); room < len(p) {
+		w.buf = append(w.buf, p[:max(room, 0)]...)
+		w.truncated = true
+		return len(p), nil
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+func (w *capWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return string(w.buf)
+}
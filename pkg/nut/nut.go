@@ -0,0 +1,115 @@
+package nut
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Client talks the NUT upsd TCP protocol (read-only subset).
+type Client struct {
+	addr     string
+	user     string
+	password string
+	timeout  time.Duration
+}
+
+// New creates a client for an upsd address such as 127.0.0.1:3493.
+func New(addr, user, password string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Client{addr: addr, user: user, password: password, timeout: timeout}
+}
+
+// Vars returns all variables of a UPS via LIST VAR.
+func (c *Client) Vars(ctx context.Context, ups string) (map[string]string, error) {
+	if ups == "" || strings.ContainsAny(ups, " \r\n\"") {
+		return nil, fmt.Errorf("invalid ups name")
+	}
+	d := net.Dialer{Timeout: c.timeout}
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	_ = conn.SetDeadline(deadline)
+
+	r := bufio.NewReader(conn)
+	if c.user != "" {
+		if err := command(conn, r, "USERNAME "+c.user); err != nil {
+			return nil, err
+		}
+		if err := command(conn, r, "PASSWORD "+c.password); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := fmt.Fprintf(conn, "LIST VAR %s\n", ups); err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("read upsd: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "ERR "):
+			return nil, fmt.Errorf("upsd: %s", strings.TrimPrefix(line, "ERR "))
+		case strings.HasPrefix(line, "BEGIN LIST VAR"):
+			continue
+		case strings.HasPrefix(line, "END LIST VAR"):
+			_, _ = fmt.Fprint(conn, "LOGOUT\n")
+			return vars, nil
+		case strings.HasPrefix(line, "VAR "):
+			name, value, ok := parseVar(line, ups)
+			if ok {
+				vars[name] = value
+			}
+		}
+	}
+}
+
+// command sends a line and expects an "OK" response.
+func command(conn net.Conn, r *bufio.Reader, line string) error {
+	if _, err := fmt.Fprintf(conn, "%s\n", line); err != nil {
+		return err
+	}
+	resp, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	resp = strings.TrimSpace(resp)
+	if !strings.HasPrefix(resp, "OK") {
+		return fmt.Errorf("upsd: %s", strings.TrimPrefix(resp, "ERR "))
+	}
+	return nil
+}
+
+// parseVar parses `VAR <ups> <name> "<value>"`.
+func parseVar(line, ups string) (string, string, bool) {
+	rest := strings.TrimPrefix(line, "VAR "+ups+" ")
+	if rest == line {
+		return "", "", false
+	}
+	i := strings.IndexByte(rest, ' ')
+	if i < 0 {
+		return "", "", false
+	}
+	name := rest[:i]
+	value := strings.TrimSpace(rest[i+1:])
+	value = strings.TrimPrefix(value, "\"")
+	value = strings.TrimSuffix(value, "\"")
+	value = strings.ReplaceAll(value, `\"`, `"`)
+	value = strings.ReplaceAll(value, `\\`, `\`)
+	return name, value, true
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"zckyachmd/lifeline/internal/api"
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+)
+
+const backupTaskList = `{"task_list":[
+	{"task_id":1,"name":"photos","status":"backingup","last_bkp_result":"done","last_bkp_progress":{"progress":42}},
+	{"task_id":2,"name":"docs","status":"none","last_bkp_result":"Failed","last_bkp_time":"2026/10/17 02:00","next_bkp_time":"2026/10/19 02:00"},
+	{"task_id":3,"name":"vm","status":"waiting","last_bkp_result":"partial"},
+	{"task_id":4,"name":"old","status":"none","last_bkp_result":""}
+]}`
+
+func TestParseBackupTasks(t *testing.T) {
+	var data map[string]any
+	if err := json.Unmarshal([]byte(backupTaskList), &data); err != nil {
+		t.Fatal(err)
+	}
+	tasks := services.ParseBackupTasks(data)
+	if len(tasks) != 4 || tasks[0].ID != "1" || tasks[0].Progress != "42%" || tasks[1].LastResult != "failed" {
+		t.Fatalf("unexpected tasks: %+v", tasks)
+	}
+	running := map[string]bool{"photos": true, "docs": false, "vm": true, "old": false}
+	failed := map[string]bool{"photos": false, "docs": true, "vm": true, "old": false}
+	for _, task := range tasks {
+		if task.Running() != running[task.Name] {
+			t.Errorf("%s: running=%v", task.Name, task.Running())
+		}
+		if task.Failed() != failed[task.Name] {
+			t.Errorf("%s: failed=%v", task.Name, task.Failed())
+		}
+	}
+}
+
+func TestBackupsReportsScheduledTaskError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("api") {
+		case "SYNO.Backup.Task":
+			_, _ = w.Write([]byte(`{"success":true,"data":` + backupTaskList + `}`))
+		default:
+			_, _ = w.Write([]byte(`{"success":false,"error":{"code":105}}`))
+		}
+	}))
+	defer srv.Close()
+	m := services.NewMonitoring(api.NewClient(srv.URL, "token"), nil, nil, &config.AppConfig{})
+	out, err := m.Backups(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"photos: RUNNING 42%", "docs: idle last=FAILED(failed)", "Scheduled tasks: error: dsm error code 105"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	active, err := m.ActiveBackups(context.Background())
+	if err != nil || strings.Join(active, ",") != "photos,vm" {
+		t.Fatalf("active backups: %v %v", active, err)
+	}
+}
@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/docker"
+)
+
+const dfSample = `{
+  "Images": [
+    {"Id": "sha256:aaaaaaaaaaaaaaaa", "RepoTags": ["<none>:<none>"], "Size": 3000, "SharedSize": 1000, "Containers": 0},
+    {"Id": "sha256:bbbbbbbbbbbbbbbb", "RepoTags": ["nginx:latest"], "Size": 5000, "SharedSize": 0, "Containers": 0},
+    {"Id": "sha256:cccccccccccccccc", "RepoTags": null, "Size": 700, "SharedSize": 0, "Containers": 1}
+  ],
+  "Containers": [
+    {"Id": "111111111111aaaa", "Names": ["/old"], "SizeRw": 400, "State": "exited"},
+    {"Id": "222222222222bbbb", "Names": ["/web"], "SizeRw": 900, "State": "running"}
+  ],
+  "BuildCache": [
+    {"ID": "x", "Size": 50, "InUse": false, "Shared": false},
+    {"ID": "y", "Size": 70, "InUse": true, "Shared": false}
+  ]
+}`
+
+func TestCleanupPreview(t *testing.T) {
+	var du docker.DiskUsage
+	if err := json.Unmarshal([]byte(dfSample), &du); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	images, err := services.BuildCleanupPreview("images", du)
+	if err != nil || images.Reclaimable != 2000 || len(images.Items) != 1 {
+		t.Fatalf("images preview: %+v %v", images, err)
+	}
+	containers, _ := services.BuildCleanupPreview("containers", du)
+	if containers.Reclaimable != 400 || !strings.Contains(containers.String(), "old (exited)") {
+		t.Fatalf("containers preview: %s", containers)
+	}
+	builder, _ := services.BuildCleanupPreview("builder", du)
+	if builder.Reclaimable != 50 {
+		t.Fatalf("builder preview: %+v", builder)
+	}
+	if _, err := services.BuildCleanupPreview("volumes", du); err == nil {
+		t.Fatal("volumes must not be a cleanup scope")
+	}
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/docker"
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+const cloudflaredMetrics = `# HELP cloudflared_tunnel_ha_connections Number of active ha connections
+# TYPE cloudflared_tunnel_ha_connections gauge
+cloudflared_tunnel_ha_connections 2
+cloudflared_tunnel_server_locations{connection_id="1",edge_location="sin11"} 1
+cloudflared_tunnel_server_locations{connection_id="0",edge_location="cgk01"} 1
+cloudflared_tunnel_server_locations{connection_id="2",edge_location="sin02"} 0
+cloudflared_tunnel_total_requests 1520
+cloudflared_tunnel_request_errors 12
+cloudflared_tunnel_tunnel_register_fail{error="context deadline exceeded",rpcName="register"} 3
+cloudflared_tunnel_tunnel_register_fail{error="quoted \"edge\", done",rpcName="register"} 1
+malformed line
+`
+
+func TestParseCloudflaredMetrics(t *testing.T) {
+	samples, err := services.ParsePromText(strings.NewReader(cloudflaredMetrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 8 {
+		t.Fatalf("expected 8 samples, got %d", len(samples))
+	}
+	cm := services.CloudflaredMetricsFrom(samples)
+	if cm.HAConnections != 2 || cm.Requests != 1520 || cm.RequestErrors != 12 {
+		t.Fatalf("unexpected metrics: %+v", cm)
+	}
+	if strings.Join(cm.EdgeLocations, ",") != "0:cgk01,1:sin11" {
+		t.Fatalf("edge locations: %v", cm.EdgeLocations)
+	}
+	if cm.RegisterFailures["context deadline exceeded"] != 3 || cm.RegisterFailures[`quoted "edge", done`] != 1 {
+		t.Fatalf("register failures: %v", cm.RegisterFailures)
+	}
+}
+
+func TestReconnectReasons(t *testing.T) {
+	logs := strings.Join([]string{
+		"INF Registered tunnel connection connIndex=0",
+		"ERR Serve tunnel error error=\"timeout: no recent network activity\" connIndex=0",
+		"INF Retrying connection in up to 2s connIndex=0",
+		"WRN Connection terminated error=\"control stream closed\" connIndex=1",
+		"ERR failed to dial to edge with quic",
+	}, "\n")
+	got := services.ReconnectReasons(logs, 3)
+	if len(got) != 3 || !strings.Contains(got[0], "Retrying connection") || !strings.Contains(got[2], "failed to dial") {
+		t.Fatalf("unexpected reasons: %q", got)
+	}
+}
+
+// cloudflaredFixture wires a fake dockerd serving mux and a cloudflared
+// metrics server serving metrics; cfg has cloudflared.metrics_addr set.
+func cloudflaredFixture(t *testing.T, mux *http.ServeMux, metrics http.HandlerFunc) (*services.DockerRuntime, *executor.Runner, *config.AppConfig) {
+	t.Helper()
+	rt := services.NewDockerRuntime(docker.New(fakeDockerd(t, mux), time.Second), nil, false)
+	runner, err := executor.New(map[string]string{}, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(metrics)
+	t.Cleanup(srv.Close)
+	return rt, runner, &config.AppConfig{
+		Cloudflared: config.CloudflaredConfig{MetricsAddr: strings.TrimPrefix(srv.URL, "http://")},
+	}
+}
+
+func cloudflaredMonitor(t *testing.T, metrics http.HandlerFunc) *services.MonitoringService {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/cloudflared/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Name":"/cloudflared","State":{"Status":"running"},"Config":{"Tty":false}}`))
+	})
+	rt, runner, cfg := cloudflaredFixture(t, mux, metrics)
+	return services.NewMonitoring(nil, rt, runner, cfg)
+}
+
+func TestCloudflaredZeroConnectionsUnhealthy(t *testing.T) {
+	m := cloudflaredMonitor(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"status":503,"readyConnections":0}`))
+	})
+	out, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "cloudflared=UNHEALTHY running but 0 edge connections") {
+		t.Fatalf("status should flag 0 connections: %q", out)
+	}
+	firing, detail, err := m.CloudflaredDownCheck(context.Background())
+	if err != nil || !firing || !strings.Contains(detail, "0 edge connections") {
+		t.Fatalf("alert should fire: %v %q %v", firing, detail, err)
+	}
+}
+
+func TestCloudflaredConnectedHealthy(t *testing.T) {
+	m := cloudflaredMonitor(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":200,"readyConnections":4}`))
+	})
+	firing, detail, err := m.CloudflaredDownCheck(context.Background())
+	if err != nil || firing || !strings.Contains(detail, "4 edge connections") {
+		t.Fatalf("alert should not fire: %v %q %v", firing, detail, err)
+	}
+}
+
+func TestCloudflaredRatesFromPreviousScrape(t *testing.T) {
+	var scrapes atomic.Int32
+	m := cloudflaredMonitor(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ready" {
+			_, _ = w.Write([]byte(`{"status":200,"readyConnections":4}`))
+			return
+		}
+		n := scrapes.Add(1)
+		_, _ = fmt.Fprintf(w, "cloudflared_tunnel_total_requests %d\ncloudflared_tunnel_request_errors 2\n", n*100)
+	})
+	out, err := m.Cloudflared(context.Background())
+	if err != nil || !strings.Contains(out, "requests: total 100, errors 2 (rates from the next /cf)") {
+		t.Fatalf("first call should report totals only: %q %v", out, err)
+	}
+	out, err = m.Cloudflared(context.Background())
+	if err != nil || !strings.Contains(out, "errors: 0.00/s over") || !strings.Contains(out, "(total 200, errors 2)") {
+		t.Fatalf("second call should report rates: %q %v", out, err)
+	}
+}
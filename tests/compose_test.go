@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/docker"
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+func TestComposeConfigValidation(t *testing.T) {
+	cases := map[string]bool{
+		"compose:\n  - name: media\n    project: media\n    file: /volume1/docker/media/compose.yaml\n":                      true,
+		"compose:\n  - name: media\n    project: media\n    file: /volume1/m.yaml\n    action: recreate\n":                   true,
+		"compose:\n  - name: media\n    project: media\n    file: media/compose.yaml\n":                                      false,
+		"compose:\n  - name: media\n    project: Media!\n    file: /volume1/m.yaml\n":                                        false,
+		"compose:\n  - name: media\n    project: media\n    file: /volume1/m.yaml\n    action: pull\n":                       false,
+		"compose:\n  - name: docker\n    project: docker\n    file: /volume1/m.yaml\n":                                       false,
+		"compose:\n  - name: a\n    project: a\n    file: /volume1/a.yaml\n  - name: a\n    project: b\n    file: /b.yaml\n": false,
+	}
+	for body, valid := range cases {
+		cfg, err := loadConfigYAML(t, body)
+		if valid && err != nil {
+			t.Errorf("valid stack rejected: %v\n%s", err, body)
+		}
+		if !valid && err == nil {
+			t.Errorf("invalid stack accepted:\n%s", body)
+		}
+		if valid && err == nil && cfg.Compose[0].Action == "" {
+			t.Errorf("action should default to restart:\n%s", body)
+		}
+	}
+}
+
+func TestComposeSteps(t *testing.T) {
+	st := config.ComposeStack{Name: "media", Project: "media", File: "/s/compose.yaml", Action: "restart"}
+	if got := services.ComposeSteps(st); len(got) != 1 || strings.Join(got[0], " ") != "compose -p media -f /s/compose.yaml restart" {
+		t.Fatalf("restart steps: %q", got)
+	}
+	st.Action = "recreate"
+	got := services.ComposeSteps(st)
+	if len(got) != 2 || strings.Join(got[0], " ") != "compose -p media -f /s/compose.yaml down" ||
+		strings.Join(got[1], " ") != "compose -p media -f /s/compose.yaml up -d --no-build --pull never" {
+		t.Fatalf("recreate steps: %q", got)
+	}
+}
+
+// composeSystem wires a fake docker CLI that records its arguments and fails
+// the step named in fail, plus a fake Engine API listing the project.
+func composeSystem(t *testing.T, fail string) (*services.SystemService, string) {
+	t.Helper()
+	dir := t.TempDir()
+	record := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$*\" >> " + record + "\n"
+	if fail != "" {
+		script += "case \" $* \" in *\" " + fail + " \"*) echo 'no such service' >&2; exit 1;; esac\n"
+	}
+	bin := filepath.Join(dir, "docker")
+	if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runner, err := executor.New(map[string]string{"docker": bin}, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Query().Get("filters"), "com.docker.compose.project=media") {
+			t.Errorf("project filter missing: %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`[{"Id":"a1","Names":["/media-jellyfin-1"],"State":"running","Status":"Up 2 seconds"}]`))
+	})
+	rt := services.NewDockerRuntime(docker.New(fakeDockerd(t, mux), time.Second), runner, false)
+	sys := services.NewSystem(nil, rt, runner, &config.AppConfig{Compose: []config.ComposeStack{
+		{Name: "media", Project: "media", File: "/volume1/media/compose.yaml", Action: "recreate"},
+	}})
+	return sys, record
+}
+
+func TestRestartStackRunsStepsInOrder(t *testing.T) {
+	sys, record := composeSystem(t, "")
+	if !sys.IsRestartable("media") || sys.CanVerify("media") {
+		t.Fatal("stack must be restartable and report state itself")
+	}
+	out, err := sys.RestartService(context.Background(), "media")
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls, _ := os.ReadFile(record)
+	want := "compose -p media -f /volume1/media/compose.yaml down\ncompose -p media -f /volume1/media/compose.yaml up -d --no-build --pull never\n"
+	if string(calls) != want {
+		t.Fatalf("unexpected docker calls:\n%s", calls)
+	}
+	for _, s := range []string{"down ok", "up ok", "media-jellyfin-1: running (Up 2 seconds)"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q in:\n%s", s, out)
+		}
+	}
+	if sys.RestartLimit("media") < 10*time.Minute {
+		t.Fatalf("job limit must cover both compose steps: %s", sys.RestartLimit("media"))
+	}
+}
+
+func TestRestartStackStopsAtFailedStep(t *testing.T) {
+	sys, record := composeSystem(t, "down")
+	out, err := sys.RestartService(context.Background(), "media")
+	if err == nil || !strings.Contains(err.Error(), "compose down failed") {
+		t.Fatalf("expected down failure, got %v", err)
+	}
+	if !strings.Contains(out, "no such service") {
+		t.Fatalf("step output not reported:\n%s", out)
+	}
+	if calls, _ := os.ReadFile(record); strings.Contains(string(calls), " up ") {
+		t.Fatalf("up must not run after a failed down:\n%s", calls)
+	}
+}
+
+func TestRestartStackCancel(t *testing.T) {
+	sys, _ := composeSystem(t, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sys.RestartService(ctx, "media"); err == nil {
+		t.Fatal("a cancelled job must not run compose steps")
+	}
+}
@@ -0,0 +1,20 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"zckyachmd/lifeline/internal/config"
+)
+
+// loadConfigYAML loads body appended to a minimal valid telegram section.
+func loadConfigYAML(t *testing.T, body string) (*config.AppConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	base := "telegram:\n  token: x\n  admin_chat_ids: [1]\n"
+	if err := os.WriteFile(path, []byte(base+body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return config.Load(path)
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/docker"
+)
+
+func TestCrashLoopTracker(t *testing.T) {
+	tr := services.NewCrashLoopTracker(10*time.Minute, 3)
+	now := time.Now()
+	if tr.Observe("app", 5, now) {
+		t.Fatal("single observation must not flag")
+	}
+	if tr.Observe("app", 6, now.Add(time.Minute)) {
+		t.Fatal("one restart must not flag")
+	}
+	if !tr.Observe("app", 8, now.Add(2*time.Minute)) {
+		t.Fatal("three restarts in window should flag")
+	}
+	if tr.Observe("app", 8, now.Add(20*time.Minute)) {
+		t.Fatal("stable count after window should clear")
+	}
+	if tr.Observe("app", 0, now.Add(21*time.Minute)) {
+		t.Fatal("recreated container should start over")
+	}
+}
+
+func TestCrashLoopCheckSamplesEachRun(t *testing.T) {
+	var inspects atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"Id":"a1","Names":["/flaky"],"State":"running"}]`))
+	})
+	mux.HandleFunc("/containers/flaky/json", func(w http.ResponseWriter, r *http.Request) {
+		restarts := 3 * inspects.Add(1)
+		_, _ = w.Write([]byte(`{"Name":"/flaky","RestartCount":` + strconv.Itoa(int(restarts)) + `,"State":{"Status":"running","Running":true}}`))
+	})
+	rt := services.NewDockerRuntime(docker.New(fakeDockerd(t, mux), time.Second), nil, false)
+	m := services.NewMonitoring(nil, rt, nil, &config.AppConfig{})
+
+	if firing, _, err := m.CrashLoopCheck(context.Background()); firing || err != nil {
+		t.Fatalf("first sample must not fire: %v %v", firing, err)
+	}
+	firing, detail, err := m.CrashLoopCheck(context.Background())
+	if err != nil || !firing || detail != "crash-looping: flaky (restarts=6)" {
+		t.Fatalf("rising restart count should fire from the alert alone: %v %q %v", firing, detail, err)
+	}
+}
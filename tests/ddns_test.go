@@ -0,0 +1,24 @@
+package tests
+
+import (
+	"testing"
+
+	"zckyachmd/lifeline/internal/services"
+)
+
+func TestDDNSMismatch(t *testing.T) {
+	data := map[string]any{
+		"records": []any{
+			map[string]any{"id": "a", "provider": "Synology", "hostname": "home.synology.me", "ip": "1.2.3.4", "status": "service_ddns_normal"},
+			map[string]any{"id": "b", "provider": "Cloudflare", "hostname": "nas.example.com", "ip": "5.6.7.8", "status": "service_ddns_normal"},
+		},
+	}
+	records := services.ParseDDNSRecords(data)
+	if len(records) != 2 || records[0].Status != "normal" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	bad := services.DDNSMismatches(records, "1.2.3.4\n")
+	if len(bad) != 1 || bad[0].Hostname != "nas.example.com" {
+		t.Fatalf("expected one mismatch, got %+v", bad)
+	}
+}
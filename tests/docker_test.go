@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/docker"
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+func frame(stream byte, payload string) []byte {
+	hdr := make([]byte, 8)
+	hdr[0] = stream
+	binary.BigEndian.PutUint32(hdr[4:], uint32(len(payload)))
+	return append(hdr, payload...)
+}
+
+// fakeDockerd serves a tiny subset of the Engine API on a unix socket.
+func fakeDockerd(t *testing.T, mux *http.ServeMux) string {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: mux}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return sock
+}
+
+func TestDockerInspectAndLogs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/cloudflared/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id":"abc","Name":"/cloudflared","RestartCount":3,"State":{"Status":"running","Running":true,"Health":{"Status":"healthy"}},"Config":{"Tty":false}}`))
+	})
+	mux.HandleFunc("/containers/cloudflared/logs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("tail") != "2" {
+			t.Errorf("tail not forwarded: %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write(frame(1, "out line\n"))
+		_, _ = w.Write(frame(2, "err line\n"))
+	})
+	mux.HandleFunc("/containers/cloudflared/restart", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("restart must be POST")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/containers/missing/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"No such container: missing"}`))
+	})
+
+	client := docker.New(fakeDockerd(t, mux), time.Second)
+	ctx := context.Background()
+
+	info, err := client.Inspect(ctx, "cloudflared")
+	if err != nil {
+		t.Fatalf("inspect: %v", err)
+	}
+	if info.State.Status != "running" || info.HealthStatus() != "healthy" || info.RestartCount != 3 {
+		t.Fatalf("unexpected inspect: %+v", info)
+	}
+
+	logs, err := client.Logs(ctx, "cloudflared", docker.LogOptions{Tail: 2})
+	if err != nil {
+		t.Fatalf("logs: %v", err)
+	}
+	if logs != "out line\nerr line\n" {
+		t.Fatalf("logs not demultiplexed: %q", logs)
+	}
+
+	if err := client.Restart(ctx, "cloudflared", 1); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if _, err := client.Inspect(ctx, "missing"); !errors.Is(err, docker.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := client.Inspect(ctx, "../etc"); err == nil {
+		t.Fatal("expected invalid name rejection")
+	}
+}
+
+func TestDockerDemuxSplitsStreams(t *testing.T) {
+	var out, errOut strings.Builder
+	stream := append(frame(1, "a"), frame(2, "b")...)
+	if err := docker.Demux(strings.NewReader(string(stream)), &out, &errOut); err != nil {
+		t.Fatalf("demux: %v", err)
+	}
+	if out.String() != "a" || errOut.String() != "b" {
+		t.Fatalf("stdout=%q stderr=%q", out.String(), errOut.String())
+	}
+}
+
+func TestDockerTimeout(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/slow/json", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+	client := docker.New(fakeDockerd(t, mux), 100*time.Millisecond)
+	start := time.Now()
+	if _, err := client.Inspect(context.Background(), "slow"); err == nil {
+		t.Fatal("expected timeout")
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("timeout not enforced")
+	}
+}
+
+func TestDockerCLIFallbackOnlyWhenUnreachable(t *testing.T) {
+	dir := t.TempDir()
+	record := filepath.Join(dir, "calls")
+	bin := filepath.Join(dir, "docker")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\necho \"$*\" >> "+record+"\necho running\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runner, err := executor.New(map[string]string{"docker": bin}, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	down := services.NewDockerRuntime(docker.New(filepath.Join(dir, "missing.sock"), time.Second), runner, true)
+	st, err := down.Status(context.Background(), "cloudflared")
+	if err != nil || st != "running" {
+		t.Fatalf("missing socket should fall back to the CLI: %q %v", st, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/slow/json", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+	mux.HandleFunc("/containers/broken/json", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"message":"boom"}`))
+	})
+	up := services.NewDockerRuntime(docker.New(fakeDockerd(t, mux), 100*time.Millisecond), runner, true)
+	for _, name := range []string{"slow", "broken", "missing"} {
+		if _, err := up.Status(context.Background(), name); err == nil {
+			t.Errorf("%s: daemon error must be returned, not masked by the CLI", name)
+		}
+	}
+	calls, _ := os.ReadFile(record)
+	if n := strings.Count(string(calls), "\n"); n != 1 {
+		t.Fatalf("CLI should run only for the unreachable socket, ran %d times:\n%s", n, calls)
+	}
+}
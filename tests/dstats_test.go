@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/docker"
+)
+
+const statsSample = `{
+  "name": "/plex",
+  "cpu_stats": {"cpu_usage": {"total_usage": 300000000}, "system_cpu_usage": 2000000000, "online_cpus": 4},
+  "precpu_stats": {"cpu_usage": {"total_usage": 100000000}, "system_cpu_usage": 1000000000},
+  "memory_stats": {"usage": 600, "limit": 4000, "stats": {"inactive_file": 100}},
+  "networks": {"eth0": {"rx_bytes": 10, "tx_bytes": 20}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}},
+  "blkio_stats": {"io_service_bytes_recursive": [{"op": "read", "value": 7}, {"op": "write", "value": 9}]}
+}`
+
+func TestCalcStats(t *testing.T) {
+	var raw docker.Stats
+	if err := json.Unmarshal([]byte(statsSample), &raw); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	st := services.CalcStats(raw)
+	if st.Name != "plex" {
+		t.Fatalf("name: %q", st.Name)
+	}
+	// 0.2s cpu over 1s system across 4 cpus = 80%
+	if math.Abs(st.CPU-80) > 0.001 {
+		t.Fatalf("cpu: %.2f", st.CPU)
+	}
+	if st.MemUsage != 500 || st.MemLimit != 4000 {
+		t.Fatalf("mem: %d/%d", st.MemUsage, st.MemLimit)
+	}
+	if st.NetRx != 11 || st.NetTx != 22 || st.BlkRead != 7 || st.BlkWrite != 9 {
+		t.Fatalf("io: %+v", st)
+	}
+}
+
+func TestSortStats(t *testing.T) {
+	in := []services.ContainerStat{{Name: "a", MemUsage: 1}, {Name: "b", MemUsage: 3}, {Name: "c", MemUsage: 2}}
+	out, err := services.SortStats(in, "mem", 2)
+	if err != nil {
+		t.Fatalf("sort: %v", err)
+	}
+	if len(out) != 2 || out[0].Name != "b" || out[1].Name != "c" {
+		t.Fatalf("unexpected order: %+v", out)
+	}
+	if _, err := services.SortStats(in, "bogus", 1); err == nil {
+		t.Fatal("expected unknown key error")
+	}
+}
+
+func TestDStatsReportsUnavailableContainers(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"Id":"a1","Names":["/plex"],"State":"running"},{"Id":"b2","Names":["/hung"],"State":"running"}]`))
+	})
+	mux.HandleFunc("/containers/plex/stats", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(statsSample))
+	})
+	mux.HandleFunc("/containers/hung/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	rt := services.NewDockerRuntime(docker.New(fakeDockerd(t, mux), time.Second), nil, false)
+	m := services.NewMonitoring(nil, rt, nil, &config.AppConfig{})
+	out, err := m.DStats(context.Background(), "cpu", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "top 1 of 1 by cpu\nplex cpu=") || !strings.HasSuffix(out, "stats unavailable: hung") {
+		t.Fatalf("failed container must be listed: %q", out)
+	}
+}
@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/services"
+)
+
+func TestWalkDiskUsage(t *testing.T) {
+	root := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(root, "a", "deep"), 0o755)
+	_ = os.MkdirAll(filepath.Join(root, "b"), 0o755)
+	_ = os.WriteFile(filepath.Join(root, "a", "deep", "big"), []byte(strings.Repeat("x", 64*1024)), 0o640)
+	_ = os.WriteFile(filepath.Join(root, "b", "small"), []byte(strings.Repeat("y", 8*1024)), 0o640)
+
+	rep, err := services.WalkDiskUsage(context.Background(), root, 1, services.DUBudget{MaxEntries: 100, Timeout: time.Second, Top: 5})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+	if rep.Truncated {
+		t.Fatal("unexpected truncation")
+	}
+	if len(rep.Dirs) != 2 || rep.Dirs[0].Path != "a" || rep.Dirs[0].Size < rep.Dirs[1].Size {
+		t.Fatalf("unexpected dirs: %+v", rep.Dirs)
+	}
+	if len(rep.Files) != 2 || rep.Files[0].Path != filepath.Join("a", "deep", "big") {
+		t.Fatalf("unexpected files: %+v", rep.Files)
+	}
+
+	limited, err := services.WalkDiskUsage(context.Background(), root, 2, services.DUBudget{MaxEntries: 2, Timeout: time.Second, Top: 5})
+	if err != nil || !limited.Truncated {
+		t.Fatalf("expected truncated walk, got %+v %v", limited, err)
+	}
+}
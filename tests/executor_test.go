@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+func TestExecutorRejectsUnsafeBinaries(t *testing.T) {
+	if _, err := executor.New(map[string]string{"sh": "sh"}, 1024); err == nil {
+		t.Fatal("relative path accepted")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "tool")
+	if err := os.WriteFile(bin, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(bin, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executor.New(map[string]string{"tool": bin}, 1024); err == nil {
+		t.Fatal("world-writable binary accepted")
+	}
+	if err := os.Chmod(bin, 0o755|os.ModeSticky|0o022); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executor.New(map[string]string{"tool": bin}, 1024); err == nil {
+		t.Fatal("sticky world-writable binary accepted")
+	}
+
+	// a writable directory further up the chain is as unsafe as the parent
+	nested := filepath.Join(dir, "open", "bin")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "open"), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	deep := filepath.Join(nested, "tool")
+	if err := os.WriteFile(deep, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := executor.New(map[string]string{"tool": deep}, 1024); err == nil {
+		t.Fatal("binary under a world-writable ancestor accepted")
+	}
+
+	r, err := executor.New(map[string]string{"gone": filepath.Join(dir, "missing")}, 1024)
+	if err != nil {
+		t.Fatalf("missing binary should not be fatal: %v", err)
+	}
+	if len(r.Missing()) != 1 || r.Available("gone") {
+		t.Fatalf("missing binary not recorded: %v", r.Missing())
+	}
+	if _, err := r.Run(context.Background(), time.Second, "gone"); err == nil {
+		t.Fatal("missing binary ran")
+	}
+}
+
+func TestExecutorRun(t *testing.T) {
+	r, err := executor.New(map[string]string{"sh": "/bin/sh"}, 16)
+	if err != nil {
+		t.Skipf("no usable /bin/sh: %v", err)
+	}
+
+	res, err := r.Run(context.Background(), 5*time.Second, "sh", "-c", "echo $HOME$PATH; exit 3")
+	if err == nil || res.ExitCode != 3 {
+		t.Fatalf("exit code: %d, err %v", res.ExitCode, err)
+	}
+	if !res.Truncated || len(res.Stdout) != 16 || !strings.HasPrefix(res.Stdout, "/usr/sbin") {
+		t.Fatalf("unexpected capped output %q truncated=%v", res.Stdout, res.Truncated)
+	}
+
+	start := time.Now()
+	// the background sleep keeps stdout open; only a group kill ends it quickly
+	_, err = r.Run(context.Background(), 200*time.Millisecond, "sh", "-c", "sleep 30 & sleep 30")
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("process group not killed, took %s", time.Since(start))
+	}
+}
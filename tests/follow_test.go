@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/docker"
+)
+
+func TestFollowLogsSplitsLines(t *testing.T) {
+	long := strings.Repeat("x", 20*1024)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/cloudflared/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Name":"/cloudflared","State":{"Status":"running"},"Config":{"Tty":false}}`))
+	})
+	mux.HandleFunc("/containers/cloudflared/logs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("follow") != "1" {
+			t.Errorf("follow not requested: %s", r.URL.RawQuery)
+		}
+		// lines split across frames and streams, CRLF endings and an
+		// oversized line without a newline
+		_, _ = w.Write(frame(1, "first li"))
+		_, _ = w.Write(frame(2, "ne\r\nsecond\n\nthi"))
+		_, _ = w.Write(frame(1, "rd\n"+long))
+	})
+	rt := services.NewDockerRuntime(docker.New(fakeDockerd(t, mux), time.Second), nil, false)
+	sys := services.NewSystem(nil, rt, nil, &config.AppConfig{})
+
+	var got []string
+	err := sys.FollowLogs(context.Background(), "cloudflared", func(l string) { got = append(got, l) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"first line", "second", "", "third", long}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %q", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d: got %.40q want %.40q", i, got[i], want[i])
+		}
+	}
+	if err := sys.FollowLogs(context.Background(), "sshd", func(string) {}); err == nil {
+		t.Fatal("unknown service must be rejected")
+	}
+}
+
+func TestFollowWindow(t *testing.T) {
+	w := services.NewFollowWindow(3, 20)
+	for _, l := range []string{"one", "two", "three", "four", "five", "six"} {
+		w.Add(l)
+	}
+	if w.Total() != 6 {
+		t.Fatalf("total: %d", w.Total())
+	}
+	if got := w.Render("hdr", 4000); got != "hdr\n\nfour\nfive\nsix" {
+		t.Fatalf("window should keep the last 3 lines: %q", got)
+	}
+	if !w.Clipped() || w.Transcript() != "one\ntwo\nthree\nfour\n" {
+		t.Fatalf("transcript should stop at the byte cap: %q clipped=%v", w.Transcript(), w.Clipped())
+	}
+
+	// oldest lines are dropped until the text fits
+	if got := w.Render("hdr", len("hdr\n\nfive\nsix")); got != "hdr\n\nfive\nsix" {
+		t.Fatalf("render should drop the oldest lines: %q", got)
+	}
+	if got := w.Render("hdr", 4); got != "hdr" {
+		t.Fatalf("header alone when nothing fits: %q", got)
+	}
+	wide := services.NewFollowWindow(2, 1<<20)
+	wide.Add(strings.Repeat("é", 10))
+	if got := wide.Render("h", 13); utf8.RuneCountInString(got) != 13 {
+		t.Fatalf("limit must count runes, not bytes: %q", got)
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/jobs"
+)
+
+func TestJobRegistryOnePerChat(t *testing.T) {
+	reg := jobs.New()
+	ctx, release, ok := reg.Start(context.Background(), 1, time.Minute)
+	if !ok {
+		t.Fatal("first job must start")
+	}
+	if _, _, ok := reg.Start(context.Background(), 1, time.Minute); ok {
+		t.Fatal("second job in the same chat must be refused")
+	}
+	_, releaseOther, ok := reg.Start(context.Background(), 2, time.Minute)
+	if !ok {
+		t.Fatal("another chat must not be blocked")
+	}
+	releaseOther()
+
+	if got := jobs.StopReason(ctx); got != "stream ended" {
+		t.Fatalf("a job still running reports a normal end, got %q", got)
+	}
+	release()
+	if ctx.Err() == nil {
+		t.Fatal("release must end the job context")
+	}
+	if reg.Cancel(1, jobs.ErrCancelled) {
+		t.Fatal("released job must be unregistered")
+	}
+	if _, release, ok := reg.Start(context.Background(), 1, time.Minute); !ok {
+		t.Fatal("chat must be free after release")
+	} else {
+		release()
+	}
+}
+
+func TestJobStopReasons(t *testing.T) {
+	reg := jobs.New()
+	ctx, release, _ := reg.Start(context.Background(), 1, time.Minute)
+	defer release()
+	if !reg.Cancel(1, jobs.ErrCancelled) {
+		t.Fatal("running job must be cancelable")
+	}
+	<-ctx.Done()
+	if got := jobs.StopReason(ctx); got != "cancelled" {
+		t.Fatalf("got %q", got)
+	}
+
+	a, releaseA, _ := reg.Start(context.Background(), 2, time.Minute)
+	defer releaseA()
+	b, releaseB, _ := reg.Start(context.Background(), 3, time.Minute)
+	defer releaseB()
+	reg.CancelAll(jobs.ErrLockdown)
+	if jobs.StopReason(a) != "lockdown" || jobs.StopReason(b) != "lockdown" {
+		t.Fatalf("lockdown must stop every job: %q %q", jobs.StopReason(a), jobs.StopReason(b))
+	}
+
+	short, releaseShort, _ := reg.Start(context.Background(), 4, 10*time.Millisecond)
+	defer releaseShort()
+	<-short.Done()
+	if !errors.Is(context.Cause(short), jobs.ErrTimeout) || jobs.StopReason(short) != "timeout" {
+		t.Fatalf("limit must report timeout, got %q", jobs.StopReason(short))
+	}
+
+	parent, stop := context.WithCancel(context.Background())
+	child, releaseChild, _ := reg.Start(parent, 5, time.Minute)
+	defer releaseChild()
+	stop()
+	if got := jobs.StopReason(child); got != "bot shutting down" {
+		t.Fatalf("parent cancel should read as shutdown, got %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/services"
+)
+
+func TestClassifyKernelMessage(t *testing.T) {
+	cases := map[string]string{
+		"Out of memory: Killed process 4242 (java) total-vm:8123456kB":               "oom",
+		"python3 invoked oom-killer: gfp_mask=0x100cca(GFP_HIGHUSER_MOVABLE)":        "oom",
+		"blk_update_request: I/O error, dev sdb, sector 123456 op 0x0:(READ)":        "io",
+		"ata3.00: failed command: READ FPDMA QUEUED":                                 "io",
+		"EXT4-fs error (device md2): ext4_lookup:1602: inode #2: comm ls: bad entry": "ext4",
+		"EXT4-fs (md2): Remounting filesystem read-only":                             "readonly",
+		"BTRFS error (device dm-1): bdev /dev/mapper/cachedev_0 errs: wr 0, rd 3":    "btrfs",
+		"e1000e: eth0 NIC Link is Up 1000 Mbps Full Duplex":                          "",
+	}
+	for msg, want := range cases {
+		if got := services.ClassifyKernelMessage(msg); got != want {
+			t.Errorf("%q: got %q want %q", msg, got, want)
+		}
+	}
+}
+
+func TestParseKmsgAndDmesg(t *testing.T) {
+	kmsg := "6,100,5000000,-;EXT4-fs (md2): mounted filesystem\n" +
+		" SUBSYSTEM=block\n" +
+		" DEVICE=b9:2\n" +
+		"3,101,7200000000,-;Out of memory: Killed process 12 (x)\n" +
+		"garbage\n"
+	recs := services.ParseKmsg(kmsg)
+	if len(recs) != 2 || recs[1].SinceBoot != 2*time.Hour || !strings.HasPrefix(recs[1].Message, "Out of memory") {
+		t.Fatalf("unexpected kmsg records: %+v", recs)
+	}
+
+	dmesg := "[    5.000000] EXT4-fs (md2): mounted filesystem\n[ 3600.500000] sd 0:0:0:0: [sda] Sense Key : Medium Error\ncontinued line\n"
+	recs = services.ParseDmesg(dmesg)
+	if len(recs) != 3 || recs[1].SinceBoot != time.Hour+500*time.Millisecond || recs[2].SinceBoot != recs[1].SinceBoot {
+		t.Fatalf("unexpected dmesg records: %+v", recs)
+	}
+}
+
+func TestKernelDigest(t *testing.T) {
+	var recs []services.KernelRecord
+	for i := 0; i < 5; i++ {
+		recs = append(recs, services.KernelRecord{SinceBoot: time.Duration(i) * time.Minute, Message: "blk_update_request: I/O error, dev sdb, sector " + string(rune('0'+i))})
+	}
+	recs = append(recs, services.KernelRecord{SinceBoot: time.Hour, Message: "EXT4-fs (md2): Remounting filesystem read-only"})
+	mounts := []services.Mount{
+		{Device: "/dev/md2", Point: "/volume1", FSType: "ext4", Options: []string{"ro", "relatime"}},
+		{Device: "proc", Point: "/proc", FSType: "proc", Options: []string{"ro"}},
+	}
+	d := services.BuildKernelDigest("/dev/kmsg", recs, mounts)
+	if d.Counts["io"] != 5 || d.Counts["readonly"] != 1 || len(d.Latest["io"]) != 3 {
+		t.Fatalf("unexpected digest: %+v", d)
+	}
+	if d.Latest["io"][2].SinceBoot != 4*time.Minute {
+		t.Fatalf("latest examples should keep the newest: %+v", d.Latest["io"])
+	}
+	if len(d.ReadOnly) != 1 || !strings.HasPrefix(d.ReadOnly[0], "/volume1") {
+		t.Fatalf("read-only data volume not flagged: %v", d.ReadOnly)
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	out := d.Render(now, now.Add(-2*time.Hour))
+	for _, want := range []string{"READ-ONLY data volumes: /volume1", "oom=0 io=5", "readonly:", "[1h0m0s ago] EXT4-fs (md2): Remounting"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("digest missing %q:\n%s", want, out)
+		}
+	}
+}
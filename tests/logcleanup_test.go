@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+)
+
+func TestFindLargeFilesAndRoots(t *testing.T) {
+	root := t.TempDir()
+	logs := filepath.Join(root, "logs")
+	if err := os.MkdirAll(filepath.Join(logs, "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(logs, "small.log"), []byte("x"), 0o640)
+	_ = os.WriteFile(filepath.Join(logs, "app", "big.log"), []byte(strings.Repeat("a", 300)), 0o640)
+	_ = os.WriteFile(filepath.Join(logs, "mid.log"), []byte(strings.Repeat("b", 200)), 0o640)
+	_ = os.WriteFile(filepath.Join(logs, "app", "big.log.1"), []byte(strings.Repeat("d", 250)), 0o640)
+	for _, skip := range []string{"old.log.2.gz", "system.journal", "data.db"} {
+		_ = os.WriteFile(filepath.Join(logs, skip), []byte(strings.Repeat("e", 400)), 0o640)
+	}
+	// wtmp-style sparse file: large apparent size, no blocks allocated
+	if f, err := os.Create(filepath.Join(logs, "wtmp.log")); err == nil {
+		_ = f.Truncate(1 << 20)
+		f.Close()
+	}
+	outside := filepath.Join(root, "secret")
+	_ = os.WriteFile(outside, []byte(strings.Repeat("c", 500)), 0o640)
+	_ = os.Symlink(outside, filepath.Join(logs, "link.log"))
+
+	got := services.FindLargeFiles([]string{logs}, 100, 10)
+	if len(got) != 3 || filepath.Base(got[0].Path) != "big.log" || filepath.Base(got[1].Path) != "big.log.1" || filepath.Base(got[2].Path) != "mid.log" {
+		t.Fatalf("unexpected candidates: %+v", got)
+	}
+
+	if !services.WithinRoots(got[0].Path, []string{logs}) {
+		t.Fatal("candidate should be within root")
+	}
+	if services.WithinRoots(filepath.Join(logs, "link.log"), []string{logs}) {
+		t.Fatal("symlink escaping root must be rejected")
+	}
+	if services.WithinRoots(logs, []string{logs}) {
+		t.Fatal("root itself must not be a target")
+	}
+}
+
+func TestCleanupLogsGzipAndCancel(t *testing.T) {
+	logs := t.TempDir()
+	path := filepath.Join(logs, "app.log")
+	if err := os.WriteFile(path, []byte(strings.Repeat("line\n", 10000)), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	sys := services.NewSystem(nil, nil, nil, &config.AppConfig{Cleanup: config.CleanupConfig{LogDirs: []string{logs}}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := sys.CleanupLogs(ctx, "gzip", []string{path}); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(path); info == nil || info.Size() == 0 {
+		t.Fatal("cancelled cleanup must leave the log intact")
+	}
+
+	out, err := sys.CleanupLogs(context.Background(), "gzip", []string{path})
+	if err != nil || !strings.Contains(out, "ok "+path) {
+		t.Fatalf("gzip rotate failed: %q %v", out, err)
+	}
+	archives, _ := filepath.Glob(path + ".*.gz")
+	if info, _ := os.Stat(path); info == nil || info.Size() != 0 || len(archives) != 1 {
+		t.Fatalf("expected truncated log and one archive: %v", archives)
+	}
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/services"
+)
+
+func TestParseLogQuery(t *testing.T) {
+	q, err := services.ParseLogQuery([]string{"--since", "15m", "--lines", "20", "--grep", "conn.*lost", "--level", "warn"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if q.Since != 15*time.Minute || q.Lines != 20 || q.Grep == nil || q.Level != "warn" {
+		t.Fatalf("unexpected query: %+v", q)
+	}
+	bad := [][]string{
+		{"--lines", "0"},
+		{"--lines", "100000"},
+		{"--since", "1y"},
+		{"--grep", "("},
+		{"--level", "trace"},
+		{"--exec", "rm"},
+		{"--since"},
+	}
+	for _, args := range bad {
+		if _, err := services.ParseLogQuery(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestFilterLogLines(t *testing.T) {
+	out := strings.Join([]string{
+		"2026-01-01T00:00:00Z INF Registered tunnel connection",
+		"2026-01-01T00:00:01Z WRN Connection lost, retrying",
+		"2026-01-01T00:00:02Z ERR Connection terminated",
+		"2026-01-01T00:00:03Z INF connection restored",
+	}, "\n")
+	q, _ := services.ParseLogQuery([]string{"--grep", "connection", "--level", "warn"})
+	res, truncated := services.FilterLogLines(out, q, time.Second)
+	if truncated {
+		t.Fatal("unexpected truncation")
+	}
+	lines := strings.Split(res, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected warn+err lines, got %q", res)
+	}
+	if !strings.Contains(lines[0], "»Connection«") {
+		t.Fatalf("match not highlighted: %q", lines[0])
+	}
+}
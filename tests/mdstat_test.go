@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zckyachmd/lifeline/internal/api"
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+)
+
+const mdstatDegraded = `Personalities : [raid1] [linear]
+md2 : active raid1 sata1p3[0] sata2p3[1](F)
+      3902296384 blocks super 1.2 [2/1] [U_]
+      [=>...................]  recovery =  8.5% (332000000/3902296384) finish=300.1min speed=190000K/sec
+
+md0 : active raid1 sata1p1[0] sata2p1[1]
+      2490176 blocks [2/2] [UU]
+
+unused devices: <none>
+`
+
+func TestParseMDStat(t *testing.T) {
+	arrays, err := services.ParseMDStat(strings.NewReader(mdstatDegraded))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(arrays) != 2 {
+		t.Fatalf("expected 2 arrays, got %d", len(arrays))
+	}
+	md2 := arrays[0]
+	if md2.Name != "md2" || md2.Level != "raid1" || !md2.Degraded() {
+		t.Fatalf("md2 should be degraded raid1: %+v", md2)
+	}
+	if len(md2.Failed) != 1 || md2.Failed[0] != "sata2p3" {
+		t.Fatalf("expected failed sata2p3: %+v", md2.Failed)
+	}
+	if md2.Action != "recovery" || md2.Progress != 8.5 {
+		t.Fatalf("unexpected rebuild progress: %s %.1f", md2.Action, md2.Progress)
+	}
+	if arrays[1].Degraded() {
+		t.Fatalf("md0 should be healthy: %+v", arrays[1])
+	}
+}
+
+const mdstatScrub = `Personalities : [raid1]
+md2 : active raid1 sata1p3[0] sata2p3[1]
+      3902296384 blocks super 1.2 [2/2] [UU]
+      [====>................]  check = 21.3% (831000000/3902296384) finish=200.0min speed=190000K/sec
+
+unused devices: <none>
+`
+
+func TestStorageIgnoresScrub(t *testing.T) {
+	cases := map[string]bool{mdstatScrub: false, mdstatDegraded: true}
+	for mdstat, problem := range cases {
+		path := filepath.Join(t.TempDir(), "mdstat")
+		if err := os.WriteFile(path, []byte(mdstat), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// DSM is unreachable, so the report comes from mdstat alone
+		m := services.NewMonitoring(api.NewClient("http://127.0.0.1:1", ""), nil, nil, &config.AppConfig{
+			Storage: config.StorageConfig{MDStatPath: path},
+		})
+		rep, err := m.StorageState(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rep.Degraded() != problem {
+			t.Errorf("problem=%v expected %v: %v", rep.Degraded(), problem, rep.Problems)
+		}
+	}
+	arrays, _ := services.ParseMDStat(strings.NewReader(mdstatScrub))
+	if arrays[0].Action != "check" || arrays[0].Rebuilding() {
+		t.Fatalf("scrub must parse but not count as a rebuild: %+v", arrays[0])
+	}
+}
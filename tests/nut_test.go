@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/nut"
+)
+
+// fakeUPSD answers LIST VAR for a single UPS like upsd does.
+func fakeUPSD(t *testing.T, vars map[string]string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				r := bufio.NewReader(c)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					f := strings.Fields(line)
+					switch {
+					case len(f) == 3 && f[0] == "LIST" && f[2] == "ups":
+						fmt.Fprint(c, "BEGIN LIST VAR ups\n")
+						for k, v := range vars {
+							fmt.Fprintf(c, "VAR ups %s \"%s\"\n", k, v)
+						}
+						fmt.Fprint(c, "END LIST VAR ups\n")
+					case len(f) == 3 && f[0] == "LIST":
+						fmt.Fprint(c, "ERR UNKNOWN-UPS\n")
+					case len(f) > 0 && f[0] == "LOGOUT":
+						fmt.Fprint(c, "OK Goodbye\n")
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestNUTOnBattery(t *testing.T) {
+	addr := fakeUPSD(t, map[string]string{
+		"battery.charge":  "87",
+		"battery.runtime": "1200",
+		"ups.load":        "23",
+		"ups.status":      "OB DISCHRG",
+		"ups.model":       "Back-UPS ES 700",
+	})
+	client := nut.New(addr, "", "", time.Second)
+	vars, err := client.Vars(context.Background(), "ups")
+	if err != nil {
+		t.Fatalf("vars: %v", err)
+	}
+	st := services.ParseNUTVars(vars)
+	if !st.OnBattery || st.LowBattery || st.Charge != 87 || st.RuntimeSec != 1200 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+	if _, err := client.Vars(context.Background(), "other"); err == nil {
+		t.Fatal("expected unknown ups error")
+	}
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"zckyachmd/lifeline/internal/api"
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+)
+
+func TestParsePackages(t *testing.T) {
+	data := map[string]any{
+		"packages": []any{
+			map[string]any{"id": "Tailscale", "name": "Tailscale", "version": "1.66", "additional": map[string]any{"status": "running"}},
+			map[string]any{"id": "HyperBackup", "name": "Hyper Backup", "version": "4.1", "status": "STOPPED"},
+			"garbage",
+		},
+	}
+	pkgs := services.ParsePackages(data)
+	if len(pkgs) != 2 || pkgs[0].ID != "HyperBackup" || pkgs[1].ID != "Tailscale" {
+		t.Fatalf("unexpected packages: %+v", pkgs)
+	}
+	if pkgs[0].Status != "stopped" || pkgs[0].Running() || !pkgs[1].Running() {
+		t.Fatalf("status not normalised: %+v", pkgs)
+	}
+}
+
+// fakeDSM serves SYNO.Core.Package list/control. The package reports
+// "stopped" until it has been listed runningAfter times after a start.
+func fakeDSM(t *testing.T, runningAfter int32, failControl bool) (*api.Client, *[]string) {
+	t.Helper()
+	var (
+		mu       sync.Mutex
+		controls []string
+		lists    atomic.Int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("api") {
+		case "SYNO.Core.Package.Control":
+			mu.Lock()
+			controls = append(controls, q.Get("method")+" "+q.Get("id"))
+			mu.Unlock()
+			if failControl {
+				_, _ = w.Write([]byte(`{"success":false,"error":{"code":4501}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"success":true}`))
+		case "SYNO.Core.Package":
+			status := "stopped"
+			if runningAfter > 0 && lists.Add(1) >= runningAfter {
+				status = "running"
+			}
+			_, _ = w.Write([]byte(`{"success":true,"data":{"packages":[{"id":"Tailscale","version":"1.66","additional":{"status":"` + status + `"}}]}}`))
+		default:
+			t.Errorf("unexpected api %s", q.Get("api"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return api.NewClient(srv.URL, "token"), &controls
+}
+
+func packageSystem(dsm *api.Client, allowed ...string) *services.SystemService {
+	if len(allowed) == 0 {
+		allowed = []string{"Tailscale"}
+	}
+	return services.NewSystem(dsm, nil, nil, &config.AppConfig{
+		Packages: config.PackagesConfig{Allowed: allowed},
+		Verify:   config.VerifyConfig{TimeoutSeconds: 2, IntervalSeconds: 1},
+	})
+}
+
+func TestRestartPackageWaitsUntilRunning(t *testing.T) {
+	dsm, controls := fakeDSM(t, 2, false)
+	sys := packageSystem(dsm)
+	if !sys.IsAllowedPackage("tailscale") || sys.IsAllowedPackage("HyperBackup") {
+		t.Fatal("allowlist must match ids case-insensitively and nothing else")
+	}
+	out, err := sys.RestartPackage(context.Background(), "tailscale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "Tailscale restarted, running after") {
+		t.Fatalf("unexpected reply: %q", out)
+	}
+	if strings.Join(*controls, ",") != "stop Tailscale,start Tailscale" {
+		t.Fatalf("unexpected control calls: %v", *controls)
+	}
+}
+
+func TestRestartPackageConfigIDCase(t *testing.T) {
+	// config spells the id differently from what DSM lists
+	dsm, _ := fakeDSM(t, 1, false)
+	out, err := packageSystem(dsm, "tailscale").RestartPackage(context.Background(), "TAILSCALE")
+	if err != nil || !strings.HasPrefix(out, "tailscale restarted, running after") {
+		t.Fatalf("poll must match the DSM id case-insensitively: %q %v", out, err)
+	}
+}
+
+func TestRestartPackageFailures(t *testing.T) {
+	dsm, controls := fakeDSM(t, 0, false)
+	sys := packageSystem(dsm)
+	if _, err := sys.RestartPackage(context.Background(), "HyperBackup"); err == nil || len(*controls) != 0 {
+		t.Fatalf("package outside the allowlist must not be touched: %v %v", err, *controls)
+	}
+	_, err := sys.RestartPackage(context.Background(), "Tailscale")
+	if err == nil || !strings.Contains(err.Error(), "did not come back up") || !strings.Contains(err.Error(), "status=stopped") {
+		t.Fatalf("expected verify timeout, got %v", err)
+	}
+
+	dsm, controls = fakeDSM(t, 1, true)
+	_, err = packageSystem(dsm).RestartPackage(context.Background(), "Tailscale")
+	if err == nil || !strings.Contains(err.Error(), "stop Tailscale: dsm error code 4501") || len(*controls) != 1 {
+		t.Fatalf("failed stop must abort before start: %v %v", err, *controls)
+	}
+}
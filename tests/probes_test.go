@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+func probeService(t *testing.T, probes []config.Probe) *services.ProbeService {
+	t.Helper()
+	runner, err := executor.New(map[string]string{}, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return services.NewProbes(probes, runner)
+}
+
+func TestProbesRunConcurrentlyInConfigOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok","version":"2024.6"}`))
+	}))
+	defer srv.Close()
+	tlsSrv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsSrv.Close()
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddr := closed.Addr().String()
+	closed.Close()
+	tlsAddr := strings.TrimPrefix(tlsSrv.URL, "https://")
+
+	ps := probeService(t, []config.Probe{
+		{Name: "ha", Type: "http", Target: srv.URL + "/api", BodyMatch: `"status":"ok"`, TimeoutSeconds: 2},
+		{Name: "ha-body", Type: "http", Target: srv.URL + "/api", BodyMatch: `maintenance`, TimeoutSeconds: 2},
+		{Name: "proxy", Type: "http", Target: srv.URL + "/down", TimeoutSeconds: 2},
+		{Name: "pve", Type: "tcp", Target: closedAddr, TimeoutSeconds: 2},
+		{Name: "cert", Type: "tls", Target: tlsAddr, MinDaysValid: 14, TimeoutSeconds: 2},
+		{Name: "cert-long", Type: "tls", Target: tlsAddr, MinDaysValid: 100000, TimeoutSeconds: 2},
+	})
+	results, err := ps.Run(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"ha": true, "ha-body": false, "proxy": false, "pve": false, "cert": true, "cert-long": false}
+	for i, r := range results {
+		if r.Name != ps.Names()[i] {
+			t.Fatalf("results out of config order: %v", results)
+		}
+		if r.OK != want[r.Name] {
+			t.Errorf("probe %s: ok=%v detail=%q", r.Name, r.OK, r.Detail)
+		}
+	}
+	table := services.FormatProbes(results)
+	if !strings.HasPrefix(table, "probes: 2/6 pass") || !strings.Contains(table, "FAIL proxy") || !strings.Contains(table, "HTTP 502") {
+		t.Fatalf("unexpected table:\n%s", table)
+	}
+
+	if _, err := ps.Run(context.Background(), "nope"); err == nil {
+		t.Fatal("unknown probe must error")
+	}
+	firing, detail, err := ps.Check("pve")(context.Background())
+	if err != nil || !firing || !strings.Contains(detail, "probe pve") {
+		t.Fatalf("alert should fire for pve: %v %q %v", firing, detail, err)
+	}
+}
+
+func TestProbeConfigValidation(t *testing.T) {
+	cases := map[string]bool{
+		"probes:\n  - name: router\n    type: icmp\n    target: 192.168.1.1\n":                               true,
+		"probes:\n  - name: app\n    type: http\n    target: https://app.lan/health\n":                       true,
+		"probes:\n  - name: app\n    type: http\n    target: ftp://app.lan\n":                                false,
+		"probes:\n  - name: pve\n    type: tcp\n    target: 192.168.1.20\n":                                  false,
+		"probes:\n  - name: x\n    type: udp\n    target: 10.0.0.1:53\n":                                     false,
+		"probes:\n  - name: x\n    type: tcp\n    target: a:1\n    timeout_seconds: 600\n":                   false,
+		"probes:\n  - name: x\n    type: tcp\n    target: a:1\n  - name: x\n    type: icmp\n    target: a\n": false,
+	}
+	for body, valid := range cases {
+		cfg, err := loadConfigYAML(t, body)
+		if valid && err != nil {
+			t.Errorf("valid probe rejected: %v\n%s", err, body)
+		}
+		if !valid && err == nil {
+			t.Errorf("invalid probe accepted:\n%s", body)
+		}
+		if valid && err == nil && cfg.Probes[0].TimeoutSeconds != 5 {
+			t.Errorf("timeout should default to 5s, got %d", cfg.Probes[0].TimeoutSeconds)
+		}
+	}
+}
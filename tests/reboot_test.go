@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"zckyachmd/lifeline/internal/services"
+)
+
+func TestParseMountsReadOnlyVolumes(t *testing.T) {
+	in := strings.Join([]string{
+		"/dev/md0 / ext4 rw,noatime,data=ordered 0 0",
+		"/dev/mapper/cachedev_0 /volume1 btrfs ro,nodev,relatime 0 0",
+		"proc /proc proc ro,nosuid 0 0",
+	}, "\n")
+	mounts, err := services.ParseMounts(strings.NewReader(in))
+	if err != nil || len(mounts) != 3 {
+		t.Fatalf("parse: %v %v", mounts, err)
+	}
+	var ro []string
+	for _, m := range mounts {
+		if m.DataVolume() && m.ReadOnly() {
+			ro = append(ro, m.Point)
+		}
+	}
+	if len(ro) != 1 || ro[0] != "/volume1" {
+		t.Fatalf("unexpected read-only data volumes: %v", ro)
+	}
+}
+
+func TestPreflightBlocked(t *testing.T) {
+	pf := services.Preflight{
+		{Name: "disk", OK: true, Detail: "/ 40% free"},
+		{Name: "raid", OK: false, Detail: "md2 recovery 12.0%"},
+	}
+	if !pf.Blocked() {
+		t.Fatal("failing check should block")
+	}
+	if !strings.Contains(pf.String(), "FAIL raid: md2 recovery 12.0%") {
+		t.Fatalf("unexpected report: %s", pf)
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+)
+
+func TestRunbookStopsAtFailedVerification(t *testing.T) {
+	sys := verifySystem(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, 1)
+	books := []config.Runbook{{
+		Name: "tunnel-down",
+		Steps: []config.RunbookStep{
+			{Wait: "10ms"},
+			{Action: "restart docker", IfHealthy: "cloudflared"},
+			{Verify: "cloudflared"},
+			{Wait: "10ms"},
+		},
+	}}
+	rb := services.NewRunbooks(nil, sys, books)
+	updates := 0
+	out, err := rb.Run(context.Background(), "tunnel-down", func(string) { updates++ })
+	if err == nil || !strings.Contains(err.Error(), "stopped at step 3") {
+		t.Fatalf("expected stop at verification, got %v", err)
+	}
+	if !strings.Contains(out, "2/4 action restart docker (if cloudflared healthy): skipped") {
+		t.Fatalf("conditional step not skipped:\n%s", out)
+	}
+	if strings.Contains(out, "4/4") || updates == 0 {
+		t.Fatalf("run continued past failure or no progress:\n%s", out)
+	}
+}
+
+func TestRunbookConfigValidation(t *testing.T) {
+	cases := map[string]bool{
+		"runbooks:\n  - name: ok\n    steps:\n      - action: restart cloudflared\n      - wait: 5s\n      - verify: cloudflared\n": true,
+		"runbooks:\n  - name: bad\n    steps:\n      - action: rm -rf /\n":                                                          false,
+		"runbooks:\n  - name: bad\n    steps:\n      - action: restart sshd\n":                                                      false,
+		"runbooks:\n  - name: bad\n    steps:\n      - check: status\n        wait: 5s\n":                                           false,
+		"runbooks:\n  - name: bad\n    steps:\n      - wait: 2h\n":                                                                  false,
+		"runbooks:\n  - name: bad\n    steps:\n      - check: status\n        if_unhealthy: nginx\n":                                false,
+	}
+	for body, valid := range cases {
+		_, err := loadConfigYAML(t, body)
+		if valid && err != nil {
+			t.Errorf("valid runbook rejected: %v\n%s", err, body)
+		}
+		if !valid && err == nil {
+			t.Errorf("invalid runbook accepted:\n%s", body)
+		}
+	}
+}
+
+func TestRunbookServicesMatchRestartAllowlist(t *testing.T) {
+	sys := services.NewSystem(nil, nil, nil, &config.AppConfig{})
+	for _, name := range config.BuiltinServices {
+		if !sys.IsRestartable(name) {
+			t.Errorf("%s passes runbook validation but /restart refuses it", name)
+		}
+		body := "runbooks:\n  - name: rb\n    steps:\n      - action: restart " + name + "\n"
+		if _, err := loadConfigYAML(t, body); err != nil {
+			t.Errorf("restart %s rejected: %v", name, err)
+		}
+	}
+	if sys.IsRestartable("sshd") {
+		t.Error("sshd must not be restartable")
+	}
+}
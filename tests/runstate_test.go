@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/services"
+)
+
+func TestRunMarkerLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run-state.json")
+
+	first := services.NewRunMarker(path)
+	if _, ok, err := first.Start("v1"); err != nil || ok {
+		t.Fatalf("first start: ok=%v err=%v", ok, err)
+	}
+	if err := first.MarkRebooting(); err != nil {
+		t.Fatal(err)
+	}
+	// the shutdown during reboot must not overwrite the reboot marker
+	if err := first.MarkStopped(); err != nil {
+		t.Fatal(err)
+	}
+
+	second := services.NewRunMarker(path)
+	prev, ok, err := second.Start("v2")
+	if err != nil || !ok || prev.State != services.RunRebooting || prev.Version != "v1" {
+		t.Fatalf("previous run: %+v ok=%v err=%v", prev, ok, err)
+	}
+
+	// a failed reboot returns to running; the later clean stop is recorded
+	if err := second.MarkRebootScheduled(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.MarkRebooting(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.MarkRunning(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.MarkStopped(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.MarkRunning(); err != nil {
+		t.Fatal(err)
+	}
+
+	third := services.NewRunMarker(path)
+	prev, ok, _ = third.Start("v2")
+	if !ok || prev.State != services.RunStopped {
+		t.Fatalf("clean stop after failed reboot not recorded: %+v", prev)
+	}
+	if err := third.MarkRebootScheduled(); err != nil {
+		t.Fatal(err)
+	}
+
+	fourth := services.NewRunMarker(path)
+	prev, ok, _ = fourth.Start("v2")
+	if !ok || prev.State != services.RunRebootScheduled {
+		t.Fatalf("crash during countdown not recorded: %+v", prev)
+	}
+
+	fifth := services.NewRunMarker(path)
+	prev, ok, _ = fifth.Start("v2")
+	if !ok || prev.State != services.RunRunning {
+		t.Fatalf("crashed run not detected: %+v", prev)
+	}
+}
+
+func TestDescribePreviousRun(t *testing.T) {
+	marked := time.Now().Add(-time.Hour)
+	after := marked.Add(time.Minute)
+	before := marked.Add(-time.Minute)
+	cases := []struct {
+		state string
+		boot  time.Time
+		want  string
+	}{
+		{services.RunStopped, before, "clean shutdown"},
+		{services.RunRebootScheduled, before, "reboot did not run"},
+		{services.RunRebooting, after, "initiated by LIFELINE completed"},
+		{services.RunRebooting, before, "did not restart"},
+		{services.RunRunning, before, "crashed"},
+		{services.RunRunning, after, "unexpectedly"},
+	}
+	for _, c := range cases {
+		got := services.DescribePreviousRun(services.RunState{State: c.state, UpdatedAt: marked}, true, c.boot)
+		if !strings.Contains(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.state, got, c.want)
+		}
+	}
+	if got := services.DescribePreviousRun(services.RunState{}, false, time.Time{}); !strings.Contains(got, "first start") {
+		t.Errorf("no marker: %q", got)
+	}
+}
@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/services"
+)
+
+const tsStatusJSON = `{
+  "Version": "1.62.0-t1234",
+  "BackendState": "Running",
+  "Self": {"HostName": "nas", "DNSName": "nas.tail1.ts.net.", "TailscaleIPs": ["100.64.0.1"], "Online": true, "KeyExpiry": "2026-10-20T00:00:00Z"},
+  "Peer": {
+    "nodekey:b": {"HostName": "phone", "TailscaleIPs": ["100.64.0.3"], "Online": false},
+    "nodekey:a": {"HostName": "laptop", "TailscaleIPs": ["100.64.0.2"], "Online": true, "CurAddr": "1.2.3.4:41641"}
+  }
+}`
+
+func TestParseTailscaleStatus(t *testing.T) {
+	st, err := services.ParseTailscaleStatus([]byte(tsStatusJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Self.HostName != "nas" || len(st.Peers) != 2 || st.Peers[0].HostName != "laptop" {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	if p := st.Problems(now); len(p) != 1 || !strings.Contains(p[0], "expires in") {
+		t.Fatalf("expected key expiry warning, got %v", p)
+	}
+	if p := st.Problems(now.Add(72 * time.Hour)); len(p) != 1 || !strings.Contains(p[0], "expired") {
+		t.Fatalf("expected expired key, got %v", p)
+	}
+
+	st.BackendState = "NeedsLogin"
+	st.AuthURL = "https://login.tailscale.com/a/abc"
+	if p := st.Problems(now.Add(-30 * 24 * time.Hour)); len(p) != 1 || !strings.Contains(p[0], "NeedsLogin") || !strings.Contains(p[0], st.AuthURL) {
+		t.Fatalf("expected NeedsLogin, got %v", p)
+	}
+}
+
+func TestParseNetcheck(t *testing.T) {
+	out := `
+Report:
+	* UDP: true
+	* IPv4: yes, 203.0.113.5:41641
+	* IPv6: no, but OS has support
+	* MappingVariesByDestIP: false
+	* Nearest DERP: Singapore
+	* DERP latency:
+		- sin: 12.3ms  (Singapore)
+		- hkg: 40.1ms  (Hong Kong)
+`
+	nc := services.ParseNetcheck(out)
+	if nc.UDP != "true" || nc.NearestDERP != "Singapore" || !strings.HasPrefix(nc.IPv4, "yes") {
+		t.Fatalf("unexpected netcheck: %+v", nc)
+	}
+	if len(nc.DERP) != 2 || nc.DERP[1].Region != "hkg" || nc.DERP[1].Latency != "40.1ms" || nc.DERP[1].Name != "Hong Kong" {
+		t.Fatalf("unexpected DERP regions: %+v", nc.DERP)
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/services"
+)
+
+const containerID = "4f1c2b3a5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708"
+
+func TestParseProcStat(t *testing.T) {
+	// comm with spaces and a closing paren must not shift fields
+	stat := "4242 (my (odd) proc) D 17 4242 4242 0 -1 4194560 500 0 0 0 250 50 0 0 20 0 4 0 12345 104857600 2560 18446744073709551615"
+	ppid, state, ticks, err := services.ParseProcStat(stat)
+	if err != nil || ppid != 17 || state != "D" || ticks != 300 {
+		t.Fatalf("got ppid=%d state=%q ticks=%d err=%v", ppid, state, ticks, err)
+	}
+	if _, _, _, err := services.ParseProcStat("garbage"); err == nil {
+		t.Fatal("malformed stat must error")
+	}
+}
+
+func TestContainerFromCgroup(t *testing.T) {
+	cases := map[string]string{
+		"0::/system.slice/docker-" + containerID + ".scope\n": containerID[:12],
+		"12:memory:/docker/" + containerID + "\n":             containerID[:12],
+		"0::/system.slice/sshd.service\n":                     "",
+	}
+	for in, want := range cases {
+		if got := services.ContainerFromCgroup(in); got != want {
+			t.Errorf("%q: got %q want %q", in, got, want)
+		}
+	}
+}
+
+func writeProc(t *testing.T, root string, pid, stat, status, cgroup string) {
+	t.Helper()
+	dir := filepath.Join(root, pid)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range map[string]string{"stat": stat, "status": status, "cgroup": cgroup} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProcTable(t *testing.T) {
+	root := t.TempDir()
+	procStat := func(pid, comm, state, ppid string, ticks int) string {
+		return pid + " (" + comm + ") " + state + " " + ppid + " 1 1 0 -1 0 0 0 0 0 " + strconv.Itoa(ticks) + " 0 0 0 20 0 1 0 100 0 0"
+	}
+	writeProc(t, root, "1", procStat("1", "init", "S", "0", 10), "Name:\tinit\nVmRSS:\t    2048 kB\n", "0::/init.scope\n")
+	writeProc(t, root, "200", procStat("200", "ffmpeg", "R", "1", 1000), "Name:\tffmpeg\nVmRSS:\t  512000 kB\n", "0::/system.slice/docker-"+containerID+".scope\n")
+	writeProc(t, root, "300", procStat("300", "rsync", "D", "1", 50), "Name:\trsync\nVmRSS:\t   10240 kB\n", "0::/system.slice/synobackup.service\n")
+	writeProc(t, root, "301", procStat("301", "defunct", "Z", "200", 0), "Name:\tdefunct\n", "")
+	if err := os.MkdirAll(filepath.Join(root, "self"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := services.ReadProcSamples(root)
+	if err != nil || len(before) != 4 {
+		t.Fatalf("samples: %v %v", before, err)
+	}
+	after := map[int]services.ProcSample{}
+	for pid, p := range before {
+		after[pid] = p
+	}
+	ff := after[200]
+	ff.Ticks += 150 // 1.5 cores for one second
+	after[200] = ff
+
+	table := services.BuildProcTable(before, after, time.Second)
+	rows, err := table.Sorted("cpu", 2)
+	if err != nil || len(rows) != 2 || rows[0].Name != "ffmpeg" || rows[0].CPU != 150 {
+		t.Fatalf("unexpected cpu order: %+v %v", rows, err)
+	}
+	if _, err := table.Sorted("io", 5); err == nil {
+		t.Fatal("unknown sort key must error")
+	}
+	out, err := table.Render("mem", 3, map[string]string{containerID[:12]: "jellyfin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"4 processes, 1 zombie, 1 D-state",
+		"200 ffmpeg R cpu=150.0% rss=500.0MiB [jellyfin]",
+		"D-state: rsync(300)",
+		"zombies: 1 under ppid 200",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
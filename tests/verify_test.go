@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+)
+
+func verifySystem(t *testing.T, ready http.HandlerFunc, timeout int) *services.SystemService {
+	t.Helper()
+	var inspects atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/cloudflared/json", func(w http.ResponseWriter, r *http.Request) {
+		status := "restarting"
+		if inspects.Add(1) > 1 {
+			status = "running"
+		}
+		_, _ = w.Write([]byte(`{"Name":"/cloudflared","State":{"Status":"` + status + `"},"Config":{"Tty":false}}`))
+	})
+	mux.HandleFunc("/containers/cloudflared/logs", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(frame(2, "failed to connect to edge\n"))
+	})
+	rt, runner, cfg := cloudflaredFixture(t, mux, ready)
+	cfg.Verify = config.VerifyConfig{TimeoutSeconds: timeout, IntervalSeconds: 1}
+	return services.NewSystem(nil, rt, runner, cfg)
+}
+
+func TestVerifyRestartHealthy(t *testing.T) {
+	sys := verifySystem(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":200,"readyConnections":4}`))
+	}, 10)
+	polls := 0
+	res := sys.VerifyRestart(context.Background(), "cloudflared", func(string, time.Duration) { polls++ })
+	if !res.Healthy || polls != 1 {
+		t.Fatalf("expected healthy after one failed poll: %+v polls=%d", res, polls)
+	}
+	if !strings.Contains(res.Detail, "4 connections") {
+		t.Fatalf("probe detail missing: %q", res.Detail)
+	}
+}
+
+func TestVerifyRestartTimeoutIncludesLogs(t *testing.T) {
+	sys := verifySystem(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"status":503,"readyConnections":0}`))
+	}, 2)
+	res := sys.VerifyRestart(context.Background(), "cloudflared", nil)
+	if res.Healthy {
+		t.Fatal("0 ready connections must not be healthy")
+	}
+	if !strings.Contains(res.Logs, "failed to connect to edge") {
+		t.Fatalf("logs not attached: %q", res.Logs)
+	}
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"zckyachmd/lifeline/internal/config"
+	"zckyachmd/lifeline/internal/services"
+	"zckyachmd/lifeline/pkg/executor"
+)
+
+func wakeSystem(t *testing.T, wake config.WakeConfig) *services.SystemService {
+	t.Helper()
+	runner, err := executor.New(map[string]string{}, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return services.NewSystem(nil, nil, runner, &config.AppConfig{Wake: wake})
+}
+
+func TestMagicPacket(t *testing.T) {
+	mac, _ := net.ParseMAC("00:11:22:33:44:55")
+	pkt := services.MagicPacket(mac)
+	if len(pkt) != 102 {
+		t.Fatalf("magic packet must be 102 bytes, got %d", len(pkt))
+	}
+	if !bytes.Equal(pkt[:6], bytes.Repeat([]byte{0xFF}, 6)) || !bytes.Equal(pkt[96:], mac) {
+		t.Fatalf("bad magic packet % x", pkt)
+	}
+}
+
+func TestWakeSendsMagicPacket(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sys := wakeSystem(t, config.WakeConfig{PollSeconds: 1, Hosts: map[string]config.WakeHost{
+		"pve": {MAC: "00:11:22:33:44:55", Broadcast: "127.0.0.1", Port: conn.LocalAddr().(*net.UDPAddr).Port},
+	}})
+	if _, err := sys.Wake(context.Background(), "nas2"); err == nil {
+		t.Fatal("unknown alias must be rejected")
+	}
+	out, err := sys.Wake(context.Background(), "pve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "00:11:22:33:44:55") {
+		t.Fatalf("unexpected reply: %q", out)
+	}
+	buf := make([]byte, 256)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFromUDP(buf)
+	if err != nil || n != 102 {
+		t.Fatalf("magic packet not received: n=%d err=%v", n, err)
+	}
+}
+
+func TestWaitReachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	sys := wakeSystem(t, config.WakeConfig{PollSeconds: 1, Hosts: map[string]config.WakeHost{
+		"up":   {MAC: "00:11:22:33:44:55", Check: ln.Addr().String()},
+		"down": {MAC: "00:11:22:33:44:66", Check: closedAddr},
+	}})
+	if up, _, err := sys.WaitReachable(context.Background(), "up", nil); !up || err != nil {
+		t.Fatalf("listening host must be reachable: %v %v", up, err)
+	}
+	up, elapsed, err := sys.WaitReachable(context.Background(), "down", nil)
+	if up || err != nil {
+		t.Fatalf("closed port must time out without error: %v %v", up, err)
+	}
+	if elapsed > 3*time.Second {
+		t.Fatalf("poll window not respected: %s", elapsed)
+	}
+}
+
+func TestWakeConfigValidation(t *testing.T) {
+	cases := map[string]bool{
+		"wake:\n  hosts:\n    pve:\n      mac: \"00:11:22:33:44:55\"\n      broadcast: 192.168.1.255\n      check: 192.168.1.20:8006\n": true,
+		"wake:\n  hosts:\n    pve:\n      mac: \"not-a-mac\"\n":                                                                         false,
+		"wake:\n  hosts:\n    pve:\n      mac: \"00:11:22:33:44:55\"\n      broadcast: ff02::1\n":                                       false,
+		"wake:\n  poll_seconds: 3600\n": false,
+	}
+	for body, valid := range cases {
+		cfg, err := loadConfigYAML(t, body)
+		if valid && err != nil {
+			t.Errorf("valid wake config rejected: %v\n%s", err, body)
+		}
+		if !valid && err == nil {
+			t.Errorf("invalid wake config accepted:\n%s", body)
+		}
+		if valid && err == nil && cfg.Wake.Hosts["pve"].Port != 9 {
+			t.Errorf("port should default to 9, got %d", cfg.Wake.Hosts["pve"].Port)
+		}
+	}
+}